@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/shared"
+	"github.com/codefly-dev/core/wool"
+)
+
+// LoadArchivedRestRoutes from the archive folder
+func (s *Service) LoadArchivedRestRoutes(ctx context.Context) ([]*RestRouteGroup, error) {
+	_, err := shared.CheckDirectoryOrCreate(ctx, s.archivedRestRoutesLocation)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot create archive folder")
+	}
+	loader, err := resources.NewExtendedRestRouteLoader[Extension](ctx, s.archivedRestRoutesLocation)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot create archived route loader")
+	}
+	err = loader.Load(ctx)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot load archived routes")
+	}
+	return loader.Groups(), nil
+}
+
+// moveRestRouteGroup keeps the route configuration, extensions included, while changing folder
+func (s *Service) moveRestRouteGroup(ctx context.Context, group *RestRouteGroup, from string, to string) error {
+	err := group.Save(ctx, to)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot save route group")
+	}
+	return s.deleteRestRouteGroup(ctx, group, from)
+}
+
+func (s *Service) deleteRestRouteGroup(ctx context.Context, group *RestRouteGroup, dir string) error {
+	file, err := resources.FilePathForRest(ctx, dir, group.ServiceUnique(), group.Path)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot get file path for route group")
+	}
+	err = os.Remove(file)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot delete route group file")
+	}
+	return nil
+}
+
+// ArchiveRestRouteGroup moves a route group without matching dependency endpoint out of the active routes
+func (s *Service) ArchiveRestRouteGroup(ctx context.Context, group *RestRouteGroup) error {
+	s.Wool.Warn("archiving routes without matching dependency endpoint",
+		wool.Field("group", group.ServiceUnique()), wool.Field("path", group.Path))
+	return s.moveRestRouteGroup(ctx, group, s.restRoutesLocation, s.archivedRestRoutesLocation)
+}
+
+// RestoreRestRouteGroup re-activates an archived route group
+func (s *Service) RestoreRestRouteGroup(ctx context.Context, group *RestRouteGroup) error {
+	s.Wool.Info("restoring archived routes", wool.Field("group", group.ServiceUnique()), wool.Field("path", group.Path))
+	return s.moveRestRouteGroup(ctx, group, s.archivedRestRoutesLocation, s.restRoutesLocation)
+}
+
+// keptArchivesLocation records the archived groups kept on request, so that sync does not ask again
+func (s *Service) keptArchivesLocation() string {
+	return path.Join(path.Dir(s.archivedRestRoutesLocation), "kept.json")
+}
+
+func archiveKey(group *RestRouteGroup) string {
+	return group.ServiceUnique() + group.Path
+}
+
+// LoadKeptArchives returns the archived groups kept on request
+func (s *Service) LoadKeptArchives() (map[string]bool, error) {
+	kept := make(map[string]bool)
+	content, err := os.ReadFile(s.keptArchivesLocation())
+	if os.IsNotExist(err) {
+		return kept, nil
+	}
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot read kept archives")
+	}
+	var keys []string
+	err = json.Unmarshal(content, &keys)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot parse kept archives")
+	}
+	for _, key := range keys {
+		kept[key] = true
+	}
+	return kept, nil
+}
+
+// SaveKeptArchives records the archived groups kept on request
+func (s *Service) SaveKeptArchives(ctx context.Context, kept map[string]bool) error {
+	if len(kept) == 0 {
+		err := os.Remove(s.keptArchivesLocation())
+		if err != nil && !os.IsNotExist(err) {
+			return s.Wool.Wrapf(err, "cannot remove kept archives")
+		}
+		return nil
+	}
+	var keys []string
+	for key := range kept {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	content, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot marshal kept archives")
+	}
+	_, err = shared.CheckDirectoryOrCreate(ctx, path.Dir(s.keptArchivesLocation()))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create archive folder")
+	}
+	err = os.WriteFile(s.keptArchivesLocation(), content, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write kept archives")
+	}
+	return nil
+}
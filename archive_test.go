@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestKeptArchives(t *testing.T) {
+	ctx := context.Background()
+	s := NewService()
+	s.Location = t.TempDir()
+	s.archivedRestRoutesLocation = s.Local("routing/archived/rest")
+
+	kept, err := s.LoadKeptArchives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 0 {
+		t.Fatalf("kept = %v; want none before any sync", kept)
+	}
+
+	want := map[string]bool{"backend/users/users": true, "backend/orders/orders": true}
+	err = s.SaveKeptArchives(ctx, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err = s.LoadKeptArchives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %v; want %v", kept, want)
+	}
+
+	err = s.SaveKeptArchives(ctx, map[string]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(s.Location, "routing/archived/kept.json")); !os.IsNotExist(err) {
+		t.Errorf("kept archives file should be removed when nothing is kept: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/codefly-dev/core/shared"
+	"github.com/codefly-dev/core/wool"
+	"github.com/go-openapi/spec"
+)
+
+// Policies for breaking changes of the public API
+const (
+	WarnOnBreakingChanges    = "warn"
+	FailOnBreakingChanges    = "fail"
+	IgnoreBreakingChanges    = "ignore"
+	publishedOpenAPILocation = "builder/openapi/published.swagger.json"
+)
+
+var httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"}
+
+func loadSwagger(file string) (*spec.Swagger, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var swagger spec.Swagger
+	err = json.Unmarshal(content, &swagger)
+	if err != nil {
+		return nil, err
+	}
+	if swagger.Paths == nil {
+		swagger.Paths = &spec.Paths{Paths: map[string]spec.PathItem{}}
+	}
+	return &swagger, nil
+}
+
+func parameterKey(param spec.Parameter) string {
+	return fmt.Sprintf("%s:%s", param.In, param.Name)
+}
+
+// schemaKind summarizes the type of a schema: its reference, or its types
+func schemaKind(schema *spec.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		return ref
+	}
+	kind := strings.Join(schema.Type, ",")
+	if schema.Items != nil && schema.Items.Schema != nil {
+		kind = fmt.Sprintf("%s of %s", kind, schemaKind(schema.Items.Schema))
+	}
+	return kind
+}
+
+// operationBreakingChanges between two versions of the same operation
+func operationBreakingChanges(route string, previous *spec.Operation, current *spec.Operation) []string {
+	var changes []string
+
+	before := make(map[string]spec.Parameter)
+	for _, param := range previous.Parameters {
+		before[parameterKey(param)] = param
+	}
+	for _, param := range current.Parameters {
+		if !param.Required {
+			continue
+		}
+		if old, ok := before[parameterKey(param)]; !ok || !old.Required {
+			changes = append(changes, fmt.Sprintf("%s: new required parameter %s", route, parameterKey(param)))
+		}
+	}
+
+	if previous.Responses == nil {
+		return changes
+	}
+	for code, old := range previous.Responses.StatusCodeResponses {
+		var response *spec.Response
+		if current.Responses != nil {
+			if r, ok := current.Responses.StatusCodeResponses[code]; ok {
+				response = &r
+			}
+		}
+		if response == nil {
+			changes = append(changes, fmt.Sprintf("%s: response %d removed", route, code))
+			continue
+		}
+		if was, is := schemaKind(old.Schema), schemaKind(response.Schema); was != "" && was != is {
+			changes = append(changes, fmt.Sprintf("%s: response %d changed from %s to %s", route, code, was, is))
+		}
+	}
+	return changes
+}
+
+// BreakingChanges of the public API between two combined OpenAPI
+func BreakingChanges(previous *spec.Swagger, current *spec.Swagger) []string {
+	var changes []string
+	for p, item := range previous.Paths.Paths {
+		next, exists := current.Paths.Paths[p]
+		for _, method := range httpMethods {
+			op := operationFor(item, method)
+			if op == nil {
+				continue
+			}
+			route := fmt.Sprintf("%s %s", method, p)
+			var nextOp *spec.Operation
+			if exists {
+				nextOp = operationFor(next, method)
+			}
+			if nextOp == nil {
+				changes = append(changes, fmt.Sprintf("%s: removed", route))
+				continue
+			}
+			changes = append(changes, operationBreakingChanges(route, op, nextOp)...)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// CheckBreakingChanges compares the combined OpenAPI to the last published one: the fail policy applies only when enforced
+func (s *Service) CheckBreakingChanges(ctx context.Context, enforce bool) error {
+	if s.Settings.BreakingChanges == IgnoreBreakingChanges {
+		return nil
+	}
+	published := s.Local(publishedOpenAPILocation)
+	for _, file := range []string{published, s.openapiDestination} {
+		exists, err := shared.FileExists(ctx, file)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot check openapi")
+		}
+		if !exists {
+			return nil
+		}
+	}
+	previous, err := loadSwagger(published)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot load published openapi")
+	}
+	current, err := loadSwagger(s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot load combined openapi")
+	}
+	changes := BreakingChanges(previous, current)
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, change := range changes {
+		s.Wool.Warn("breaking change in public API", wool.Field("change", change))
+	}
+	if enforce && s.Settings.BreakingChanges == FailOnBreakingChanges {
+		version := "unknown"
+		if previous.Info != nil {
+			version = previous.Info.Version
+		}
+		return s.Wool.NewError("%d breaking changes in public API since version %s", len(changes), version)
+	}
+	return nil
+}
+
+// PublishOpenAPI keeps the combined OpenAPI as reference for the next breaking changes check, unless it has no route
+func (s *Service) PublishOpenAPI(ctx context.Context) error {
+	exists, err := shared.FileExists(ctx, s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot check combined openapi")
+	}
+	if !exists {
+		return nil
+	}
+	current, err := loadSwagger(s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot load combined openapi")
+	}
+	if len(current.Paths.Paths) == 0 {
+		// an empty reference would replace the previous one, and the removed routes would go unreported
+		s.Wool.Debug("no route in combined openapi: nothing to publish")
+		return nil
+	}
+	content, err := os.ReadFile(s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot read combined openapi")
+	}
+	published := s.Local(publishedOpenAPILocation)
+	_, err = shared.CheckDirectoryOrCreate(ctx, path.Dir(published))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create published openapi folder")
+	}
+	err = os.WriteFile(published, content, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write published openapi")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func swaggerWith(paths map[string]spec.PathItem) *spec.Swagger {
+	return &spec.Swagger{SwaggerProps: spec.SwaggerProps{Paths: &spec.Paths{Paths: paths}}}
+}
+
+func operation(params []spec.Parameter, responses map[int]spec.Response) *spec.Operation {
+	op := &spec.Operation{}
+	op.Parameters = params
+	if responses != nil {
+		op.Responses = &spec.Responses{ResponsesProps: spec.ResponsesProps{StatusCodeResponses: responses}}
+	}
+	return op
+}
+
+func response(schema *spec.Schema) spec.Response {
+	return spec.Response{ResponseProps: spec.ResponseProps{Schema: schema}}
+}
+
+func TestOperationFor(t *testing.T) {
+	get, post := &spec.Operation{}, &spec.Operation{}
+	item := spec.PathItem{PathItemProps: spec.PathItemProps{Get: get, Post: post}}
+	tcs := []struct {
+		method string
+		want   *spec.Operation
+	}{
+		{"GET", get},
+		{"POST", post},
+		{"DELETE", nil},
+		{"TRACE", nil},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.method, func(t *testing.T) {
+			if got := operationFor(item, tc.method); got != tc.want {
+				t.Errorf("operationFor(%s) = %v; want %v", tc.method, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	ok := map[int]spec.Response{200: response(spec.StringProperty())}
+	optional := spec.QueryParam("page")
+	required := *spec.QueryParam("page").AsRequired()
+	previous := swaggerWith(map[string]spec.PathItem{
+		"/users": {PathItemProps: spec.PathItemProps{
+			Get:    operation([]spec.Parameter{*optional}, ok),
+			Delete: operation(nil, ok),
+		}},
+	})
+	tcs := []struct {
+		name    string
+		current *spec.Swagger
+		changes []string
+	}{
+		{"same", previous, nil},
+		{"new route", swaggerWith(map[string]spec.PathItem{
+			"/users": {PathItemProps: spec.PathItemProps{
+				Get:    operation([]spec.Parameter{*optional}, ok),
+				Delete: operation(nil, ok),
+				Post:   operation([]spec.Parameter{required}, ok),
+			}},
+		}), nil},
+		{"removed path", swaggerWith(map[string]spec.PathItem{}),
+			[]string{"DELETE /users: removed", "GET /users: removed"}},
+		{"removed method", swaggerWith(map[string]spec.PathItem{
+			"/users": {PathItemProps: spec.PathItemProps{Get: operation([]spec.Parameter{*optional}, ok)}},
+		}), []string{"DELETE /users: removed"}},
+		{"parameter now required", swaggerWith(map[string]spec.PathItem{
+			"/users": {PathItemProps: spec.PathItemProps{
+				Get:    operation([]spec.Parameter{required}, ok),
+				Delete: operation(nil, ok),
+			}},
+		}), []string{"GET /users: new required parameter query:page"}},
+		{"response removed", swaggerWith(map[string]spec.PathItem{
+			"/users": {PathItemProps: spec.PathItemProps{
+				Get:    operation([]spec.Parameter{*optional}, ok),
+				Delete: operation(nil, map[int]spec.Response{204: response(nil)}),
+			}},
+		}), []string{"DELETE /users: response 200 removed"}},
+		{"response changed", swaggerWith(map[string]spec.PathItem{
+			"/users": {PathItemProps: spec.PathItemProps{
+				Get:    operation([]spec.Parameter{*optional}, map[int]spec.Response{200: response(spec.ArrayProperty(spec.StringProperty()))}),
+				Delete: operation(nil, ok),
+			}},
+		}), []string{"GET /users: response 200 changed from string to array of string"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := BreakingChanges(previous, tc.current); !reflect.DeepEqual(got, tc.changes) {
+				t.Errorf("BreakingChanges() = %v; want %v", got, tc.changes)
+			}
+		})
+	}
+}
+
+func TestPublishOpenAPI(t *testing.T) {
+	ctx := context.Background()
+	tcs := []struct {
+		name      string
+		paths     map[string]spec.PathItem
+		published bool
+	}{
+		{"no path", nil, false},
+		{"paths", map[string]spec.PathItem{"/store/catalog/items": {}}, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService()
+			s.Location = t.TempDir()
+			s.openapiDestination = s.Local("api.swagger.json")
+			content, err := json.Marshal(swaggerWith(tc.paths))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(s.openapiDestination, content, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.PublishOpenAPI(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = os.Stat(s.Local(publishedOpenAPILocation))
+			if published := err == nil; published != tc.published {
+				t.Errorf("published = %v; want %v", published, tc.published)
+			}
+		})
+	}
+}
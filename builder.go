@@ -4,9 +4,17 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/codefly-dev/core/agents/communicate"
 	dockerhelpers "github.com/codefly-dev/core/agents/helpers/docker"
 	"github.com/codefly-dev/core/agents/services"
+	"github.com/codefly-dev/core/configurations"
 	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
 	agentv0 "github.com/codefly-dev/core/generated/go/codefly/services/agent/v0"
 	builderv0 "github.com/codefly-dev/core/generated/go/codefly/services/builder/v0"
@@ -21,6 +29,9 @@ type ImportRoute struct {
 	*resources.RestRoute
 	service string
 	module  string
+
+	// group path for known routes
+	group string
 }
 
 type ImportGRPC struct {
@@ -39,7 +50,26 @@ func (imp *ImportRoute) Unique() string {
 type Builder struct {
 	*Service
 
-	syncForREST []*ImportRoute
+	syncForREST    []*ImportRoute
+	staleForREST   []*ImportRoute
+	changedForREST []*ChangedRoute
+	// archived groups whose endpoint is still missing: deleted on request or by the sync policy
+	orphanedForREST []*RestRouteGroup
+	// archived groups kept on request: not asked about again
+	keptArchives map[string]bool
+
+	// signatures of the dependency routes
+	signatures        map[string]*Signature
+	missingSignatures bool
+
+	// bulkExposure is set when an interactive sync edits the exposure of the known routes on request
+	bulkExposure bool
+}
+
+// ChangedRoute is a known route whose signature changed in its backend
+type ChangedRoute struct {
+	*ImportRoute
+	changes []string
 }
 
 func NewBuilder() *Builder {
@@ -59,6 +89,11 @@ func (s *Builder) Load(ctx context.Context, req *builderv0.LoadRequest) (*builde
 
 	requirements.Localize(s.Location)
 
+	err = s.ValidateSyncPolicy()
+	if err != nil {
+		return s.Builder.LoadError(err)
+	}
+
 	err = s.Setup(ctx)
 	if err != nil {
 		return s.Builder.LoadError(err)
@@ -104,6 +139,19 @@ func (s *Builder) Load(ctx context.Context, req *builderv0.LoadRequest) (*builde
 	return s.Builder.LoadResponse()
 }
 
+// ValidateSyncPolicy checks the sync-policy as written in the service configuration
+func (s *Builder) ValidateSyncPolicy() error {
+	if s.Settings.SyncPolicy == nil {
+		return nil
+	}
+	file := s.Local(resources.ServiceConfigurationName)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot read service configuration")
+	}
+	return ValidateSyncPolicy(resources.ServiceConfigurationName, content)
+}
+
 func (s *Builder) Init(ctx context.Context, req *builderv0.InitRequest) (*builderv0.InitResponse, error) {
 	defer s.Wool.Catch()
 	ctx = s.Wool.Inject(ctx)
@@ -129,8 +177,34 @@ func (s *Builder) Init(ctx context.Context, req *builderv0.InitRequest) (*builde
 
 func (s *Builder) Update(ctx context.Context, req *builderv0.UpdateRequest) (*builderv0.UpdateResponse, error) {
 	defer s.Wool.Catch()
+	ctx = s.Wool.Inject(ctx)
+
+	s.Wool.Info("updating service", wool.Field("agent", agent.Version))
 
-	return &builderv0.UpdateResponse{}, nil
+	changed, skipped, unrecorded, err := s.UpdateTemplates(ctx)
+	if err != nil {
+		return s.Builder.UpdateError(err)
+	}
+
+	resp, err := s.Builder.UpdateResponse()
+	if err != nil {
+		return resp, err
+	}
+	var messages []string
+	if len(changed) > 0 {
+		messages = append(messages, fmt.Sprintf("updated: %s", strings.Join(changed, ", ")))
+	}
+	if len(skipped) > 0 {
+		messages = append(messages, fmt.Sprintf("kept modified: %s", strings.Join(skipped, ", ")))
+	}
+	if len(unrecorded) > 0 {
+		messages = append(messages, fmt.Sprintf("left unchanged, refreshed by the next update unless modified: %s", strings.Join(unrecorded, ", ")))
+	}
+	resp.State.Message = "nothing to update"
+	if len(messages) > 0 {
+		resp.State.Message = strings.Join(messages, "; ")
+	}
+	return resp, nil
 }
 
 func (s *Builder) UnknownRestRoutes(ctx context.Context) ([]*resources.RestRouteGroup, error) {
@@ -146,12 +220,46 @@ func (s *Builder) UnknownRestRoutes(ctx context.Context) ([]*resources.RestRoute
 			updatedGroups = append(updatedGroups, group)
 			continue
 		}
-		err := baseGroup.Delete(ctx, s.restRoutesLocation)
+		err := s.ArchiveRestRouteGroup(ctx, group)
 		if err != nil {
-			return nil, s.Wool.Wrapf(err, "cannot delete group")
+			return nil, s.Wool.Wrapf(err, "cannot archive group")
 		}
 	}
 
+	// archived routes come back with their endpoint
+	archived, err := s.LoadArchivedRestRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.keptArchives, err = s.LoadKeptArchives()
+	if err != nil {
+		return nil, err
+	}
+	s.orphanedForREST = nil
+	for _, group := range archived {
+		baseGroup := resources.UnwrapRestRouteGroup(group)
+		matchingEndpoint := resources.FindEndpointForRestRoute(ctx, s.DependencyEndpoints, baseGroup)
+		if matchingEndpoint != nil {
+			err = s.RestoreRestRouteGroup(ctx, group)
+			if err != nil {
+				return nil, s.Wool.Wrapf(err, "cannot restore group")
+			}
+			// archived again later, it is asked about again
+			delete(s.keptArchives, archiveKey(group))
+			updatedGroups = append(updatedGroups, group)
+			continue
+		}
+		if s.keptArchives[archiveKey(group)] {
+			continue
+		}
+		s.orphanedForREST = append(s.orphanedForREST, group)
+	}
+	err = s.SaveKeptArchives(ctx, s.keptArchives)
+	if err != nil {
+		return nil, err
+	}
+	s.RestRouteGroups = updatedGroups
+
 	var known []*resources.RestRouteGroup
 	for _, group := range updatedGroups {
 		known = append(known, resources.UnwrapRestRouteGroup(group))
@@ -161,10 +269,68 @@ func (s *Builder) UnknownRestRoutes(ctx context.Context) ([]*resources.RestRoute
 	return resources.DetectNewRoutesFromEndpoints(ctx, s.DependencyEndpoints, known), nil
 }
 
+// StaleRestRoutes are known routes that are not in their dependency endpoint anymore
+func (s *Builder) StaleRestRoutes(ctx context.Context) []*ImportRoute {
+	var stale []*ImportRoute
+	for _, group := range s.RestRouteGroups {
+		baseGroup := resources.UnwrapRestRouteGroup(group)
+		endpoint := resources.FindEndpointForRestRoute(ctx, s.DependencyEndpoints, baseGroup)
+		if endpoint == nil {
+			continue
+		}
+		available := make(map[string]bool)
+		for _, g := range resources.IsRest(ctx, endpoint).Groups {
+			for _, r := range g.Routes {
+				available[fmt.Sprintf("%s %s", r.Path, resources.ConvertHTTPMethodFromProto(r.Method))] = true
+			}
+		}
+		for _, route := range baseGroup.Routes {
+			if available[fmt.Sprintf("%s %s", route.Path, route.Method)] {
+				continue
+			}
+			stale = append(stale, &ImportRoute{RestRoute: route, service: group.Service, module: group.Module, group: group.Path})
+		}
+	}
+	return stale
+}
+
+// ChangedRestRoutes detects known routes whose parameters, request body or responses changed
+func (s *Builder) ChangedRestRoutes(ctx context.Context) error {
+	var err error
+	s.signatures, err = SignaturesFromEndpoints(ctx, s.DependencyEndpoints)
+	if err != nil {
+		return err
+	}
+	s.changedForREST = []*ChangedRoute{}
+	for _, group := range s.RestRouteGroups {
+		for _, route := range group.Routes {
+			imp := &ImportRoute{RestRoute: &route.RestRoute, service: group.Service, module: group.Module, group: group.Path}
+			signature, ok := s.signatures[imp.Unique()]
+			if !ok {
+				continue
+			}
+			if route.Extension.Signature == nil {
+				s.missingSignatures = true
+				continue
+			}
+			changes := route.Extension.Signature.Changes(signature)
+			if len(changes) == 0 {
+				continue
+			}
+			s.Wool.Warn("route signature changed", wool.Field("route", imp.Unique()), wool.Field("changes", strings.Join(changes, ", ")))
+			s.changedForREST = append(s.changedForREST, &ChangedRoute{ImportRoute: imp, changes: changes})
+		}
+	}
+	return nil
+}
+
 func (s *Builder) UpdateAvailableRoutesForSync(ctx context.Context) error {
 	defer s.Wool.Catch()
 
 	newRestRoutes, err := s.UnknownRestRoutes(ctx)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot detect new REST routes")
+	}
 	s.Wool.Debug("unknown REST groups", wool.SliceCountField(newRestRoutes))
 
 	s.syncForREST = []*ImportRoute{}
@@ -177,10 +343,31 @@ func (s *Builder) UpdateAvailableRoutesForSync(ctx context.Context) error {
 		}
 	}
 
-	if len(s.syncForREST) == 0 {
+	s.staleForREST = s.StaleRestRoutes(ctx)
+	for _, imp := range s.staleForREST {
+		s.Wool.Warn("route not provided by its service anymore", wool.Field("route", imp.Unique()))
+	}
+
+	err = s.ChangedRestRoutes(ctx)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot detect changed REST routes")
+	}
+
+	if !s.routeChanges() && !s.orphanDecisions() {
+		if s.interactiveSync() && s.Settings.SyncPolicy.EditsExposure() {
+			return s.RegisterBulkExposure(ctx)
+		}
+		return nil
+	}
+	s.Wool.Debug("found route changes",
+		wool.Field("new", len(s.syncForREST)),
+		wool.Field("stale", len(s.staleForREST)),
+		wool.Field("changed", len(s.changedForREST)),
+		wool.Field("archived", len(s.orphanedForREST)))
+
+	if !s.interactiveSync() {
 		return nil
 	}
-	s.Wool.Debug("found new routes", wool.SliceCountField(s.syncForREST))
 
 	// register communication for Sync
 	err = s.Communication.Register(ctx, communicate.New[builderv0.SyncRequest](s.syncQuestions()))
@@ -191,6 +378,11 @@ func (s *Builder) UpdateAvailableRoutesForSync(ctx context.Context) error {
 	return nil
 }
 
+// routeChanges is true when dependency routes were added, removed or changed
+func (s *Builder) routeChanges() bool {
+	return len(s.syncForREST) > 0 || len(s.staleForREST) > 0 || len(s.changedForREST) > 0
+}
+
 func exposeRestWithAuth(imp *ImportRoute) string {
 	return fmt.Sprintf("expose-rest-with-auth-%s", imp.Unique())
 }
@@ -201,6 +393,41 @@ func hiddenRest(imp *ImportRoute) string {
 	return fmt.Sprintf("hidden-rest-%s", imp.Unique())
 }
 
+func staleRest(imp *ImportRoute) string {
+	return fmt.Sprintf("stale-rest-%s", imp.Unique())
+}
+func removeStaleRest(imp *ImportRoute) string {
+	return fmt.Sprintf("remove-stale-rest-%s", imp.Unique())
+}
+func keepStaleRest(imp *ImportRoute) string {
+	return fmt.Sprintf("keep-stale-rest-%s", imp.Unique())
+}
+
+// orphanDecisions are needed for archived groups whose endpoint is still missing: asked, or pruned by the sync policy
+func (s *Builder) orphanDecisions() bool {
+	return len(s.orphanedForREST) > 0 && (s.interactiveSync() || s.Settings.SyncPolicy.Prunes())
+}
+
+func orphanedRest(group *RestRouteGroup) string {
+	return fmt.Sprintf("orphaned-rest-%s%s", group.ServiceUnique(), group.Path)
+}
+func deleteOrphanedRest(group *RestRouteGroup) string {
+	return fmt.Sprintf("delete-orphaned-rest-%s%s", group.ServiceUnique(), group.Path)
+}
+func keepOrphanedRest(group *RestRouteGroup) string {
+	return fmt.Sprintf("keep-orphaned-rest-%s%s", group.ServiceUnique(), group.Path)
+}
+
+func changedRest(imp *ImportRoute) string {
+	return fmt.Sprintf("changed-rest-%s", imp.Unique())
+}
+func keepChangedRest(imp *ImportRoute) string {
+	return fmt.Sprintf("keep-changed-rest-%s", imp.Unique())
+}
+func hideChangedRest(imp *ImportRoute) string {
+	return fmt.Sprintf("hide-changed-rest-%s", imp.Unique())
+}
+
 func (s *Builder) syncQuestions() *communicate.Sequence {
 	var questions []*agentv0.Question
 	if len(s.syncForREST) > 0 {
@@ -217,70 +444,248 @@ func (s *Builder) syncQuestions() *communicate.Sequence {
 				&agentv0.Message{Name: hiddenRest(imp), Message: "No (internal only)"}),
 		)
 	}
+	for _, imp := range s.staleForREST {
+		questions = append(questions,
+			communicate.NewChoice(&agentv0.Message{Name: staleRest(imp),
+				Message:     fmt.Sprintf("REST route: %s %s is not provided by service <%s> from module <%s> anymore", imp.Path, imp.Method, imp.service, imp.module),
+				Description: fmt.Sprintf("Corresponding route on the API service is /%s/%s%s", imp.module, imp.service, imp.Path)},
+				&agentv0.Message{Name: removeStaleRest(imp), Message: "Remove it from the gateway"},
+				&agentv0.Message{Name: keepStaleRest(imp), Message: "Keep it"}),
+		)
+	}
+
+	for _, changed := range s.changedForREST {
+		imp := changed.ImportRoute
+		questions = append(questions,
+			communicate.NewChoice(&agentv0.Message{Name: changedRest(imp),
+				Message:     fmt.Sprintf("REST route: %s %s for service <%s> from module <%s> changed (%s)", imp.Path, imp.Method, imp.service, imp.module, strings.Join(changed.changes, ", ")),
+				Description: fmt.Sprintf("Corresponding route on the API service is /%s/%s%s", imp.module, imp.service, imp.Path)},
+				&agentv0.Message{Name: keepChangedRest(imp), Message: "Keep exposure and protection settings"},
+				&agentv0.Message{Name: hideChangedRest(imp), Message: "Hide it until reviewed"}),
+		)
+	}
+
+	for _, group := range s.orphanedForREST {
+		questions = append(questions,
+			communicate.NewChoice(&agentv0.Message{Name: orphanedRest(group),
+				Message:     fmt.Sprintf("REST routes %s of service <%s> from module <%s> are archived: the endpoint is missing", group.Path, group.Service, group.Module),
+				Description: "Archived routes are restored with their configuration when the endpoint comes back"},
+				&agentv0.Message{Name: keepOrphanedRest(group), Message: "Keep them archived"},
+				&agentv0.Message{Name: deleteOrphanedRest(group), Message: "Delete them"}),
+		)
+	}
 
 	return communicate.NewSequence(questions...)
 }
 
+// interactiveSync is true when sync questions are asked: otherwise the sync policy applies
+func (s *Builder) interactiveSync() bool {
+	return s.Builder.SyncMode != nil && s.Builder.SyncMode.Communicate
+}
+
+func exposureFromSession(session *communicate.ServerSession, imp *ImportRoute) (Exposure, error) {
+	expose, err := session.Choice(imp.Unique())
+	if err != nil {
+		return "", err
+	}
+	switch expose.Option {
+	case exposeRestWithAuth(imp):
+		return ExposureProtected, nil
+	case exposeRestWithoutAuth(imp):
+		return ExposurePublic, nil
+	default:
+		return ExposureHidden, nil
+	}
+}
+
 func (s *Builder) Sync(ctx context.Context, req *builderv0.SyncRequest) (*builderv0.SyncResponse, error) {
 	defer s.Wool.Catch()
 	ctx = s.Wool.Inject(ctx)
 
-	session, err := s.Communication.Done(ctx, communicate.Channel[builderv0.SyncRequest]())
+	var err error
+	if s.bulkExposure {
+		err = s.BulkExposureSync(ctx)
+	} else {
+		err = s.syncRoutes(ctx)
+	}
 	if err != nil {
 		return s.Builder.SyncError(err)
 	}
-	if session == nil {
+
+	// Checked on the edited routes, and only warn: failing would block the route edits fixing the breaking changes
+	err = s.combineOpenAPI(ctx)
+	if err != nil {
+		s.Wool.Warn("cannot combine openapi to check breaking changes", wool.ErrField(err))
 		return s.Builder.SyncResponse()
 	}
+	err = s.CheckBreakingChanges(ctx, false)
+	if err != nil {
+		return s.Builder.SyncError(err)
+	}
+	return s.Builder.SyncResponse()
+}
 
-	s.Wool.Debug("states", wool.NullableField("answers", session.GetState()))
+// syncRoutes applies the route changes found by UpdateAvailableRoutesForSync
+func (s *Builder) syncRoutes(ctx context.Context) error {
+	if !s.routeChanges() && !s.missingSignatures && !s.orphanDecisions() {
+		return s.WriteRouteInventory(ctx)
+	}
+
+	var session *communicate.ServerSession
+	var err error
+	if s.interactiveSync() {
+		session, err = s.Communication.Done(ctx, communicate.Channel[builderv0.SyncRequest]())
+		if err != nil {
+			return err
+		}
+		if session != nil {
+			s.Wool.Debug("states", wool.NullableField("answers", session.GetState()))
+		}
+	} else {
+		s.Wool.Info("applying sync policy to REST routes", wool.Field("new", len(s.syncForREST)), wool.Field("stale", len(s.staleForREST)))
+	}
 
 	restRouteLoader, err := resources.NewExtendedRestRouteLoader[Extension](ctx, s.restRoutesLocation)
 	if err != nil {
-		return s.Builder.SyncError(err)
+		return err
 	}
 
 	err = restRouteLoader.Load(ctx)
 	if err != nil {
-		return s.Builder.SyncError(err)
+		return err
 	}
 
 	for _, imp := range s.syncForREST {
-		expose, err := session.Choice(imp.Unique())
-		if err != nil {
-			return s.Builder.SyncError(err)
+		exposure := s.Settings.SyncPolicy.Exposure(imp)
+		if session != nil {
+			exposure, err = exposureFromSession(session, imp)
+			if err != nil {
+				return err
+			}
 		}
+		s.Wool.Debug("exposure", wool.Field("route", imp.Unique()), wool.Field("exposure", exposure))
 		group := restRouteLoader.GroupFor(resources.ServiceUnique(imp.module, imp.service), imp.Path)
 		if group == nil {
 			group = &RestRouteGroup{Module: imp.module, Service: imp.service, Path: imp.Path}
 			restRouteLoader.AddGroup(group)
 		}
-		route := RestRoute{RestRoute: *imp.RestRoute}
-		if expose.Option == hiddenRest(imp) {
-			route.Extension.Exposed = false
-		} else {
-			s.Wool.Debug("exposing", wool.Field("key", expose.Option))
-			route.Extension.Exposed = true
-			if expose.Option == exposeRestWithAuth(imp) {
-				route.Extension.Protected = true
+		route := RestRoute{RestRoute: *imp.RestRoute, Extension: exposure.Extension()}
+		group.Add(route)
+	}
+
+	for _, imp := range s.staleForREST {
+		remove := false
+		if session != nil {
+			choice, err := session.Choice(staleRest(imp))
+			if err != nil {
+				return err
 			}
+			remove = choice.Option == removeStaleRest(imp)
+		}
+		if !remove {
+			s.Wool.Warn("keeping stale route", wool.Field("route", imp.Unique()))
+			continue
+		}
+		s.Wool.Info("removing stale route", wool.Field("route", imp.Unique()))
+		group := restRouteLoader.GroupFor(resources.ServiceUnique(imp.module, imp.service), imp.group)
+		if group == nil {
+			continue
+		}
+		var routes []*RestRoute
+		for _, r := range group.Routes {
+			if r.Path == imp.Path && r.Method == imp.Method {
+				continue
+			}
+			routes = append(routes, r)
+		}
+		group.Routes = routes
+	}
+
+	for _, changed := range s.changedForREST {
+		imp := changed.ImportRoute
+		hide := false
+		if session != nil {
+			choice, err := session.Choice(changedRest(imp))
+			if err != nil {
+				return err
+			}
+			hide = choice.Option == hideChangedRest(imp)
+		}
+		s.Wool.Info("route changed", wool.Field("route", imp.Unique()), wool.Field("changes", strings.Join(changed.changes, ", ")), wool.Field("hidden", hide))
+		if !hide {
+			continue
+		}
+		group := restRouteLoader.GroupFor(resources.ServiceUnique(imp.module, imp.service), imp.group)
+		if group == nil {
+			continue
+		}
+		for _, r := range group.Routes {
+			if r.Path == imp.Path && r.Method == imp.Method {
+				r.Extension.Exposed = false
+			}
+		}
+	}
+
+	if s.orphanDecisions() {
+		for _, group := range s.orphanedForREST {
+			prune := s.Settings.SyncPolicy.Prunes()
+			if session != nil {
+				choice, err := session.Choice(orphanedRest(group))
+				if err != nil {
+					return err
+				}
+				prune = choice.Option == deleteOrphanedRest(group)
+			}
+			if !prune {
+				s.keptArchives[archiveKey(group)] = true
+				continue
+			}
+			s.Wool.Info("deleting archived routes", wool.Field("group", group.ServiceUnique()), wool.Field("path", group.Path))
+			err = s.deleteRestRouteGroup(ctx, group, s.archivedRestRoutesLocation)
+			if err != nil {
+				return err
+			}
+		}
+		err = s.SaveKeptArchives(ctx, s.keptArchives)
+		if err != nil {
+			return err
 		}
-		group.Add(route)
 	}
+
+	s.recordSignatures(restRouteLoader.Groups())
 	err = restRouteLoader.Save(ctx)
 	if err != nil {
-		return s.Builder.SyncError(err)
+		return err
+	}
+	for _, group := range restRouteLoader.Groups() {
+		if len(group.Routes) > 0 {
+			continue
+		}
+		err = s.deleteRestRouteGroup(ctx, group, s.restRoutesLocation)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Get all the routes
 	err = s.LoadRestRoutes(ctx)
 	if err != nil {
-		return s.Builder.SyncError(err)
+		return err
 	}
 
-	// Create the configuration
+	return s.WriteRouteInventory(ctx)
+}
 
-	return s.Builder.SyncResponse()
+// recordSignatures keeps the current backend signatures of the routes
+func (s *Builder) recordSignatures(groups []*RestRouteGroup) {
+	for _, group := range groups {
+		for _, r := range group.Routes {
+			imp := &ImportRoute{RestRoute: &r.RestRoute, service: group.Service, module: group.Module}
+			if signature, ok := s.signatures[imp.Unique()]; ok {
+				r.Extension.Signature = signature
+			}
+		}
+	}
 }
 
 type Env struct {
@@ -288,8 +693,155 @@ type Env struct {
 	Value string
 }
 
+// dockerfileEscaper escapes the characters interpreted in a double quoted Dockerfile string
+var dockerfileEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+// Quoted is the value as a double quoted Dockerfile string
+func (e Env) Quoted() string {
+	return `"` + dockerfileEscaper.Replace(e.Value) + `"`
+}
+
+// envKey is a valid environment variable name
+var envKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ContainerPort is the port KrakenD listens on in the built image: KRAKEND_PORT or the standard REST port
+func (s *Settings) ContainerPort() (uint16, error) {
+	value, ok := s.ImageEnvironmentVariables["KRAKEND_PORT"]
+	if !ok {
+		return standards.Port(standards.REST), nil
+	}
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid KRAKEND_PORT: %q", value)
+	}
+	return uint16(port), nil
+}
+
 type DockerTemplating struct {
-	Envs []Env
+	Image string
+	Port  uint16
+	Envs  []Env
+
+	// Static build
+	Static         bool
+	Settings       string
+	FlexibleConfig bool
+
+	// Docs folder served by the gateway
+	Docs bool
+	// GRPC descriptors of the forwarded RPCs
+	GRPC bool
+}
+
+// DockerEnvs are the environment variables of the built image: flexible configuration defaults, then settings
+func (s *Builder) DockerEnvs(docker *DockerTemplating) ([]Env, error) {
+	envs := map[string]string{
+		"FC_ENABLE":   "1",
+		"FC_SETTINGS": "/app/settings",
+	}
+	if docker.Static && !docker.FlexibleConfig {
+		envs = map[string]string{"FC_ENABLE": "0"}
+	}
+	for key, value := range s.Settings.ImageEnvironmentVariables {
+		if !envKey.MatchString(key) {
+			return nil, s.Wool.NewError("invalid image environment variable name: %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, s.Wool.NewError("image environment variable %s cannot span several lines", key)
+		}
+		envs[key] = value
+	}
+	var out []Env
+	for key, value := range envs {
+		out = append(out, Env{Key: key, Value: value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// environmentSettings is where Deploy keeps the rendered routing settings of an environment
+func environmentSettings(env string) string {
+	return fmt.Sprintf("builder/settings/%s/routing.json", env)
+}
+
+// clusterHost is the Kubernetes service name of a dependency
+func (s *Builder) clusterHost(endpoint *basev0.Endpoint) string {
+	namespace := s.Settings.BuildNamespace
+	if namespace == "" {
+		namespace = shared.ToDNSCase(endpoint.Module)
+	}
+	return fmt.Sprintf("%s.%s.svc.cluster.local", shared.ToDNSCase(endpoint.Service), namespace)
+}
+
+// environmentConfiguration of the service in configurations/{env}, nil if there is none
+func (s *Builder) environmentConfiguration(ctx context.Context, env string) (*basev0.Configuration, error) {
+	dir := s.Local("configurations/%s", env)
+	exists, err := shared.DirectoryExists(ctx, dir)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot check configuration folder")
+	}
+	if !exists {
+		return nil, nil
+	}
+	infos, err := configurations.LoadConfigurationInformationsFromFiles(ctx, dir)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot load configurations of %s", env)
+	}
+	return &basev0.Configuration{Infos: infos}, nil
+}
+
+// environmentValidators protecting the routes with the auth configuration of the environment
+func (s *Builder) environmentValidators(ctx context.Context, env string, conf *basev0.Configuration) error {
+	s.validators = nil
+	if !s.requiresAuth {
+		return nil
+	}
+	if conf == nil {
+		return s.Wool.NewError("protected routes need the auth configuration of environment %s", env)
+	}
+	validators, err := s.CreateValidators(ctx, conf)
+	if err != nil {
+		return err
+	}
+	s.validators = validators
+	return nil
+}
+
+// StaticBuild renders the routing settings of the target environment to bake them in the image
+func (s *Builder) StaticBuild(ctx context.Context, docker *DockerTemplating) error {
+	if s.Settings.BuildEnvironment == "" {
+		return s.Wool.NewError("static build requires a build-environment setting")
+	}
+	if s.Settings.DisableFlexibleConfig && s.Settings.AuthMode == APIKeyAuth {
+		return s.Wool.NewError("API keys are read from a secret when the gateway starts: a static build needs the flexible configuration")
+	}
+	s.environment = s.Settings.BuildEnvironment
+	conf, err := s.environmentConfiguration(ctx, s.Settings.BuildEnvironment)
+	if err != nil {
+		return err
+	}
+	err = s.environmentValidators(ctx, s.Settings.BuildEnvironment, conf)
+	if err != nil {
+		return err
+	}
+	mappings := containerNetworkMappings(ctx, s.DependencyEndpoints, s.clusterHost)
+	rendered, err := s.createConfig(ctx, mappings, resources.NewContainerNetworkAccess())
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot render routing settings")
+	}
+	settings := environmentSettings(s.Settings.BuildEnvironment)
+	_, err = shared.CheckDirectoryOrCreate(ctx, path.Dir(s.Local(settings)))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create settings directory")
+	}
+	err = os.WriteFile(s.Local(settings), rendered, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write routing settings")
+	}
+	docker.Static = true
+	docker.Settings = settings
+	docker.FlexibleConfig = !s.Settings.DisableFlexibleConfig
+	return nil
 }
 
 func (s *Builder) Build(ctx context.Context, req *builderv0.BuildRequest) (*builderv0.BuildResponse, error) {
@@ -306,7 +858,52 @@ func (s *Builder) Build(ctx context.Context, req *builderv0.BuildRequest) (*buil
 		return s.Builder.BuildError(fmt.Errorf("invalid docker runtimeImage name: %s", image.Name))
 	}
 
-	docker := DockerTemplating{}
+	err = s.combineOpenAPI(ctx)
+	if err != nil {
+		return s.Builder.BuildError(err)
+	}
+
+	err = s.CheckBreakingChanges(ctx, true)
+	if err != nil {
+		return s.Builder.BuildError(err)
+	}
+
+	docker := DockerTemplating{Image: s.RuntimeImage().FullName()}
+
+	switch s.Settings.Build {
+	case StaticBuild:
+		err = s.StaticBuild(ctx, &docker)
+		if err != nil {
+			return s.Builder.BuildError(err)
+		}
+	case "", FlexibleBuild:
+	default:
+		return s.Builder.BuildError(s.Wool.NewError("unknown build kind: %s", s.Settings.Build))
+	}
+
+	if s.Settings.ServeOpenAPI {
+		err = s.WriteDocs(ctx)
+		if err != nil {
+			return s.Builder.BuildError(err)
+		}
+		docker.Docs = true
+	}
+	if s.Settings.GRPCForwarding {
+		_, err = shared.CheckDirectoryOrCreate(ctx, s.Local(grpcDescriptors))
+		if err != nil {
+			return s.Builder.BuildError(err)
+		}
+		docker.GRPC = true
+	}
+
+	docker.Envs, err = s.DockerEnvs(&docker)
+	if err != nil {
+		return s.Builder.BuildError(err)
+	}
+	docker.Port, err = s.Settings.ContainerPort()
+	if err != nil {
+		return s.Builder.BuildError(err)
+	}
 
 	err = shared.DeleteFile(ctx, s.Local("builder/Dockerfile"))
 	if err != nil {
@@ -333,10 +930,25 @@ func (s *Builder) Build(ctx context.Context, req *builderv0.BuildRequest) (*buil
 	}
 	s.Builder.WithDockerImages(image)
 
+	err = s.PublishOpenAPI(ctx)
+	if err != nil {
+		return s.Builder.BuildError(err)
+	}
+
 	return s.Builder.BuildResponse()
 
 }
 
+// combineOpenAPI regenerates the combined OpenAPI from the dependency endpoints
+func (s *Builder) combineOpenAPI(ctx context.Context) error {
+	var err error
+	s.restEndpoint, err = resources.FindRestEndpoint(ctx, s.Endpoints)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot find REST endpoint")
+	}
+	return s.writeOpenAPI(ctx, s.DependencyEndpoints)
+}
+
 type LoadBalancer struct {
 	Enabled bool
 	Host    string
@@ -344,7 +956,11 @@ type LoadBalancer struct {
 
 type Parameters struct {
 	LoadBalancer
+	// Port is the container port of the gateway
+	Port          uint16
 	Configuration string
+	// MetricsPort is scraped by Prometheus: 0 when metrics are disabled
+	MetricsPort uint16
 }
 
 func (s *Builder) Deploy(ctx context.Context, req *builderv0.DeploymentRequest) (*builderv0.DeploymentResponse, error) {
@@ -352,6 +968,14 @@ func (s *Builder) Deploy(ctx context.Context, req *builderv0.DeploymentRequest)
 
 	s.Builder.LogDeployRequest(req, s.Wool.Debug)
 
+	if s.Settings.Deployment == ComposeDeployment {
+		err := s.ComposeDeploy(ctx, req)
+		if err != nil {
+			return s.Builder.DeployError(err)
+		}
+		return s.Builder.DeployResponse()
+	}
+
 	s.EnvironmentVariables.SetRunning()
 
 	var k *builderv0.KubernetesDeployment
@@ -387,6 +1011,17 @@ func (s *Builder) Deploy(ctx context.Context, req *builderv0.DeploymentRequest)
 		return s.Builder.DeployError(err)
 	}
 
+	port, err := s.Settings.ContainerPort()
+	if err != nil {
+		return s.Builder.DeployError(err)
+	}
+
+	err = s.environmentValidators(ctx, req.Environment.Name, req.Configuration)
+	if err != nil {
+		return s.Builder.DeployError(err)
+	}
+
+	s.environment = req.Environment.Name
 	conf, err := s.createConfig(ctx, req.DependenciesNetworkMappings, resources.NewContainerNetworkAccess())
 	if err != nil {
 		return nil, s.Wool.Wrapf(err, "cannot write config")
@@ -397,21 +1032,227 @@ func (s *Builder) Deploy(ctx context.Context, req *builderv0.DeploymentRequest)
 		SecretMap: secrets,
 		Parameters: Parameters{
 			LoadBalancer:  LoadBalancer{},
+			Port:          port,
 			Configuration: string(conf),
+			MetricsPort:   s.metricsPort(),
 		},
 	}
 
-	err = s.Builder.KustomizeDeploy(ctx, req.Environment, k, deploymentFS, params)
+	restEndpoint, err := resources.FindRestEndpoint(ctx, s.Endpoints)
+	if err == nil && restEndpoint != nil {
+		public, err := resources.FindNetworkInstanceInNetworkMappings(ctx, req.NetworkMappings, restEndpoint, resources.NewPublicNetworkAccess())
+		if err == nil && public != nil {
+			err = s.WritePostmanEnvironment(ctx, req.Environment.Name, public.Address)
+			if err != nil {
+				return s.Builder.DeployError(err)
+			}
+		}
+	}
+
+	switch s.Settings.Deployment {
+	case HelmDeployment:
+		err = s.HelmDeploy(ctx, req.Environment, k, params)
+	case "", KustomizeDeployment:
+		err = s.Builder.KustomizeDeploy(ctx, req.Environment, k, deploymentFS, params)
+	default:
+		err = s.Wool.NewError("unknown deployment kind: %s", s.Settings.Deployment)
+	}
+	if err != nil {
+		return s.Builder.DeployError(err)
+	}
 
 	return s.Builder.DeployResponse()
 }
 
+// HelmDeploy generates a Helm chart from the same parameters as the kustomize deployment
+func (s *Builder) HelmDeploy(ctx context.Context, env *basev0.Environment, k *builderv0.KubernetesDeployment, params services.DeploymentParameters) error {
+	defer s.Wool.Catch()
+
+	base, err := s.Builder.CreateKubernetesBase(ctx, env, k.Namespace, k.BuildContext)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create base")
+	}
+	err = shared.EmptyDir(ctx, k.Destination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot empty destination")
+	}
+	wrapper := &services.DeploymentWrapper{DeploymentBase: base, Deployment: params}
+	err = s.Templates(ctx, wrapper, services.WithDeployment(deploymentFS, "helm").WithDestination(k.Destination))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot generate helm chart")
+	}
+	return nil
+}
+
 /* Creation */
 
+// Creation questions
+const (
+	AuthModeOption       = "auth-mode"
+	ExposureOption       = "default-exposure"
+	CorsOriginsOption    = "cors-origins"
+	GRPCForwardingOption = "grpc-forwarding"
+)
+
+// Options for creation: the first option of a choice is the default
 func (s *Builder) Options() []*agentv0.Question {
+	return []*agentv0.Question{
+		communicate.NewChoice(&agentv0.Message{Name: AuthModeOption, Message: "Default authentication for protected routes?"},
+			&agentv0.Message{Name: NoAuth, Message: "None"},
+			&agentv0.Message{Name: JWTAuth, Message: "JWT"},
+			&agentv0.Message{Name: FakeAuth, Message: "Fake authentication (local development)"},
+			&agentv0.Message{Name: APIKeyAuth, Message: "API key (KrakenD Enterprise)"},
+		),
+		communicate.NewChoice(&agentv0.Message{Name: ExposureOption, Message: "Default exposure of new routes when syncing?"},
+			&agentv0.Message{Name: string(ExposureHidden), Message: "Hidden"},
+			&agentv0.Message{Name: string(ExposureProtected), Message: "Exposed (authenticated)"},
+			&agentv0.Message{Name: string(ExposurePublic), Message: "Exposed (non authenticated)"},
+		),
+		communicate.NewStringInput(&agentv0.Message{Name: CorsOriginsOption, Message: "Allowed CORS origins (comma separated)?"}, "*"),
+		communicate.NewConfirm(&agentv0.Message{Name: GRPCForwardingOption, Message: "Forward the RPCs of gRPC dependencies (KrakenD Enterprise)?"}, false),
+	}
+}
+
+// defaultChoice is the first option of a choice
+func defaultChoice(options []*agentv0.Question, name string) string {
+	for _, opt := range options {
+		if opt.Message.Name != name {
+			continue
+		}
+		if choice := opt.GetChoice(); choice != nil && len(choice.Options) > 0 {
+			return choice.Options[0].Name
+		}
+	}
+	return ""
+}
+
+// creationAnswers fills the settings from the creation session, or from the defaults without one
+func (s *Builder) creationAnswers(session *communicate.ServerSession) error {
+	options := s.Options()
+	authMode := defaultChoice(options, AuthModeOption)
+	exposure := defaultChoice(options, ExposureOption)
+	origins, err := communicate.GetDefaultStringInput(options, CorsOriginsOption)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot get default CORS origins")
+	}
+	grpc, err := communicate.GetDefaultConfirm(options, GRPCForwardingOption)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot get default gRPC forwarding")
+	}
+	if session != nil {
+		choice, err := session.Choice(AuthModeOption)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot get auth mode")
+		}
+		authMode = choice.Option
+		choice, err = session.Choice(ExposureOption)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot get default exposure")
+		}
+		exposure = choice.Option
+		origins, err = session.GetInputString(CorsOriginsOption)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot get CORS origins")
+		}
+		grpc, err = session.Confirm(GRPCForwardingOption)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot get gRPC forwarding")
+		}
+	}
+
+	if authMode == NoAuth && Exposure(exposure) == ExposureProtected {
+		return s.Wool.NewError("new routes cannot be authenticated by default without auth mode")
+	}
+	s.Settings.AuthMode = authMode
+	if Exposure(exposure) != ExposureHidden {
+		s.Settings.SyncPolicy = &SyncPolicy{Default: Exposure(exposure)}
+	}
+	s.Settings.CorsOrigins = nil
+	for _, origin := range strings.Split(origins, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		s.Settings.CorsOrigins = append(s.Settings.CorsOrigins, origin)
+	}
+	if _, err = Cors(s.Settings.CorsOrigins); err != nil {
+		return s.Wool.Wrapf(err, "invalid CORS origins")
+	}
+	if len(s.Settings.CorsOrigins) == 1 && s.Settings.CorsOrigins[0] == "*" {
+		s.Settings.CorsOrigins = nil
+	}
+	s.Settings.GRPCForwarding = grpc
+	return nil
+}
+
+// authSeeds are the auth.yaml written for the local environment at creation
+var authSeeds = map[string]string{
+	JWTAuth: `jwt:
+  audience: YOUR_AUDIENCE
+  url: YOUR_BASE_URL
+`,
+	FakeAuth: `fake:
+  user-auth-id: test-auth-id
+`,
+	APIKeyAuth: `# keys are in the api_keys secret configuration
+api-key: {}
+`,
+}
+
+// apiKeysSeed is the api_keys secret configuration written with the api-key auth mode
+const apiKeysSeed = `KEYS=YOUR_API_KEY
+`
+
+// SeedAuthConfiguration writes configurations/local/auth.yaml for the auth mode, without overwriting it.
+// Other environments are not known at creation, and a seed there would deploy placeholders or fake authentication:
+// deploying protected routes fails until their auth.yaml is written.
+func (s *Builder) SeedAuthConfiguration(ctx context.Context) error {
+	seed, ok := authSeeds[s.Settings.AuthMode]
+	if !ok {
+		return nil
+	}
+	dir := s.Local("configurations/%s", resources.LocalEnvironment().Name)
+	_, err := shared.CheckDirectoryOrCreate(ctx, dir)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create configuration folder")
+	}
+	file := path.Join(dir, "auth.yaml")
+	exists, err := shared.FileExists(ctx, file)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot check auth configuration")
+	}
+	if exists {
+		return nil
+	}
+	err = os.WriteFile(file, []byte(seed), 0o600)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write auth configuration")
+	}
+	if s.Settings.AuthMode != APIKeyAuth {
+		return nil
+	}
+	err = os.WriteFile(path.Join(dir, fmt.Sprintf("%s.secret.env", APIKeysSecret)), []byte(apiKeysSeed), 0o600)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write API keys secret configuration")
+	}
 	return nil
 }
 
+// SeedRoutingFolder creates the route folders used by the gateway
+func (s *Builder) SeedRoutingFolder(ctx context.Context) error {
+	dirs := []string{s.restRoutesLocation}
+	if s.Settings.GRPCForwarding {
+		dirs = append(dirs, s.Local(grpcDescriptors))
+	}
+	for _, dir := range dirs {
+		_, err := shared.CheckDirectoryOrCreate(ctx, dir)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot create routing folder")
+		}
+	}
+	return s.copyConfigTemplate()
+}
+
 func (s *Builder) createCommunicate() *communicate.Sequence {
 	return communicate.NewSequence(s.Options()...)
 }
@@ -420,15 +1261,32 @@ func (s *Builder) Create(ctx context.Context, req *builderv0.CreateRequest) (*bu
 	defer s.Wool.Catch()
 	ctx = s.Wool.Inject(ctx)
 
+	var session *communicate.ServerSession
 	if s.Builder.CreationMode.Communicate {
 		s.Wool.Debug("using communicate mode")
-		_, err := s.Communication.Done(ctx, communicate.Channel[builderv0.CreateRequest]())
+		var err error
+		session, err = s.Communication.Done(ctx, communicate.Channel[builderv0.CreateRequest]())
 		if err != nil {
 			return s.Builder.CreateError(err)
 		}
 	}
 
-	err := s.Templates(ctx, s.Information, services.WithFactory(factoryFS))
+	err := s.creationAnswers(session)
+	if err != nil {
+		return s.Builder.CreateError(err)
+	}
+
+	_, _, err = s.WriteFactoryFiles(ctx)
+	if err != nil {
+		return s.Builder.CreateError(err)
+	}
+
+	err = s.SeedAuthConfiguration(ctx)
+	if err != nil {
+		return s.Builder.CreateError(err)
+	}
+
+	err = s.SeedRoutingFolder(ctx)
 	if err != nil {
 		return s.Builder.CreateError(err)
 	}
@@ -438,7 +1296,7 @@ func (s *Builder) Create(ctx context.Context, req *builderv0.CreateRequest) (*bu
 		return s.Builder.CreateError(err)
 	}
 
-	err = s.Templates(ctx, s.Information, services.WithTemplate(routingFS, "routing", "routing"))
+	err = s.WriteRouteInventory(ctx)
 	if err != nil {
 		return s.Builder.CreateError(err)
 	}
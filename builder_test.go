@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestEnvQuoted(t *testing.T) {
+	tcs := []struct {
+		value  string
+		quoted string
+	}{
+		{"1", `"1"`},
+		{"/app/settings", `"/app/settings"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{"$HOME", `"\$HOME"`},
+	}
+	for _, tc := range tcs {
+		if got := (Env{Key: "KEY", Value: tc.value}).Quoted(); got != tc.quoted {
+			t.Errorf("Quoted(%q) = %s; want %s", tc.value, got, tc.quoted)
+		}
+	}
+}
+
+func TestContainerPort(t *testing.T) {
+	tcs := []struct {
+		name  string
+		envs  map[string]string
+		port  uint16
+		fails bool
+	}{
+		{"default", nil, 8080, false},
+		{"custom", map[string]string{"KRAKEND_PORT": "9000"}, 9000, false},
+		{"invalid", map[string]string{"KRAKEND_PORT": "http"}, 0, true},
+		{"zero", map[string]string{"KRAKEND_PORT": "0"}, 0, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := Settings{ImageEnvironmentVariables: tc.envs}
+			port, err := settings.ContainerPort()
+			if (err != nil) != tc.fails || port != tc.port {
+				t.Errorf("ContainerPort() = %d, %v; want %d, fails %v", port, err, tc.port, tc.fails)
+			}
+		})
+	}
+}
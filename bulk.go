@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codefly-dev/core/agents/communicate"
+	agentv0 "github.com/codefly-dev/core/generated/go/codefly/services/agent/v0"
+	builderv0 "github.com/codefly-dev/core/generated/go/codefly/services/builder/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/wool"
+)
+
+const bulkRoutes = "bulk-routes"
+
+// bulkQuestions select the routes moved to each exposure: the others keep theirs
+var bulkQuestions = []struct {
+	name     string
+	exposure Exposure
+	message  string
+}{
+	{"bulk-protected", ExposureProtected, "Routes to expose with authentication"},
+	{"bulk-public", ExposurePublic, "Routes to expose without authentication"},
+	{"bulk-hidden", ExposureHidden, "Routes to hide"},
+}
+
+// exposureOf a known route
+func exposureOf(route *RestRoute) Exposure {
+	switch {
+	case !route.Extension.Exposed:
+		return ExposureHidden
+	case route.Extension.Protected:
+		return ExposureProtected
+	default:
+		return ExposurePublic
+	}
+}
+
+// routeUnique identifies a route of a group like ImportRoute.Unique
+func routeUnique(group *RestRouteGroup, route *RestRoute) string {
+	return fmt.Sprintf("%s%s %s", group.ServiceUnique(), route.Path, route.Method)
+}
+
+func bulkOption(question string, unique string) string {
+	return fmt.Sprintf("%s:%s", question, unique)
+}
+
+// knownRoute is a route with its group
+type knownRoute struct {
+	group *RestRouteGroup
+	route *RestRoute
+}
+
+// sortedRoutes by module and service, then path and method
+func sortedRoutes(groups []*RestRouteGroup) []knownRoute {
+	var routes []knownRoute
+	for _, group := range groups {
+		for _, route := range group.Routes {
+			routes = append(routes, knownRoute{group: group, route: route})
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.group.ServiceUnique() != b.group.ServiceUnique() {
+			return a.group.ServiceUnique() < b.group.ServiceUnique()
+		}
+		if a.route.Path != b.route.Path {
+			return a.route.Path < b.route.Path
+		}
+		return a.route.Method < b.route.Method
+	})
+	return routes
+}
+
+// routesSummary lists the current exposure of the routes, grouped by module and service
+func routesSummary(routes []knownRoute) string {
+	var lines []string
+	current := ""
+	for _, known := range routes {
+		if unique := known.group.ServiceUnique(); unique != current {
+			current = unique
+			lines = append(lines, fmt.Sprintf("%s:", unique))
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s: %s", known.route.Method, known.route.Path, exposureOf(known.route)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bulkExposureQuestions show the current routes and let select the ones changing exposure
+func (s *Builder) bulkExposureQuestions() *communicate.Sequence {
+	routes := sortedRoutes(s.RestRouteGroups)
+	questions := []*agentv0.Question{
+		communicate.Display(&agentv0.Message{Name: bulkRoutes, Message: fmt.Sprintf("Current routes\n%s", routesSummary(routes))}, nil),
+	}
+	for _, question := range bulkQuestions {
+		var options []*agentv0.Message
+		for _, known := range routes {
+			if exposureOf(known.route) == question.exposure {
+				continue
+			}
+			unique := routeUnique(known.group, known.route)
+			options = append(options, &agentv0.Message{
+				Name:    bulkOption(question.name, unique),
+				Message: fmt.Sprintf("%s %s %s", known.group.ServiceUnique(), known.route.Method, known.route.Path),
+			})
+		}
+		questions = append(questions, communicate.NewSelection(&agentv0.Message{Name: question.name, Message: question.message}, options...))
+	}
+	return communicate.NewSequence(questions...)
+}
+
+// RegisterBulkExposure asks for the exposure changes of the known routes
+func (s *Builder) RegisterBulkExposure(ctx context.Context) error {
+	if len(s.RestRouteGroups) == 0 {
+		s.Wool.Info("no route to edit")
+		return nil
+	}
+	s.bulkExposure = true
+	err := s.Communication.Register(ctx, communicate.New[builderv0.SyncRequest](s.bulkExposureQuestions()))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot communicate for bulk exposure")
+	}
+	return nil
+}
+
+// exposureChanges maps the selected routes to their new exposure: a route selected twice is an error
+func exposureChanges(selected map[string][]string) (map[string]Exposure, error) {
+	changes := make(map[string]Exposure)
+	for _, question := range bulkQuestions {
+		for _, option := range selected[question.name] {
+			unique := strings.TrimPrefix(option, bulkOption(question.name, ""))
+			if previous, ok := changes[unique]; ok {
+				return nil, fmt.Errorf("route %s selected to be both %s and %s", unique, previous, question.exposure)
+			}
+			changes[unique] = question.exposure
+		}
+	}
+	return changes, nil
+}
+
+// BulkExposureSync applies the selected exposure changes to the known routes
+func (s *Builder) BulkExposureSync(ctx context.Context) error {
+	session, err := s.Communication.Done(ctx, communicate.Channel[builderv0.SyncRequest]())
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+
+	selected := make(map[string][]string)
+	for _, question := range bulkQuestions {
+		answer, err := session.Selection(question.name)
+		if err != nil {
+			return err
+		}
+		selected[question.name] = answer.Selected
+	}
+	changes, err := exposureChanges(selected)
+	if err != nil {
+		return err
+	}
+
+	restRouteLoader, err := resources.NewExtendedRestRouteLoader[Extension](ctx, s.restRoutesLocation)
+	if err != nil {
+		return err
+	}
+	err = restRouteLoader.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range restRouteLoader.Groups() {
+		for _, route := range group.Routes {
+			unique := routeUnique(group, route)
+			exposure, ok := changes[unique]
+			if !ok {
+				continue
+			}
+			s.Wool.Info("changing exposure", wool.Field("route", unique), wool.Field("from", exposureOf(route)), wool.Field("to", exposure))
+			extension := exposure.Extension()
+			route.Extension.Exposed = extension.Exposed
+			route.Extension.Protected = extension.Protected
+		}
+	}
+
+	s.recordSignatures(restRouteLoader.Groups())
+	err = restRouteLoader.Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.LoadRestRoutes(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.WriteRouteInventory(ctx)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codefly-dev/core/resources"
+)
+
+func TestExposureChanges(t *testing.T) {
+	tcs := []struct {
+		name     string
+		selected map[string][]string
+		changes  map[string]Exposure
+		fails    bool
+	}{
+		{"nothing selected changes nothing", nil, map[string]Exposure{}, false},
+		{"each selection moves its routes", map[string][]string{
+			"bulk-protected": {"bulk-protected:store/catalog/items GET"},
+			"bulk-hidden":    {"bulk-hidden:store/catalog/items DELETE"},
+		}, map[string]Exposure{
+			"store/catalog/items GET":    ExposureProtected,
+			"store/catalog/items DELETE": ExposureHidden,
+		}, false},
+		{"route selected twice", map[string][]string{
+			"bulk-public": {"bulk-public:store/catalog/items GET"},
+			"bulk-hidden": {"bulk-hidden:store/catalog/items GET"},
+		}, nil, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, err := exposureChanges(tc.selected)
+			if tc.fails {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, tc.changes) {
+				t.Errorf("changes = %v; want %v", changes, tc.changes)
+			}
+		})
+	}
+}
+
+func TestRoutesSummary(t *testing.T) {
+	route := func(p string, method resources.HTTPMethod, extension Extension) *RestRoute {
+		return &RestRoute{RestRoute: resources.RestRoute{Path: p, Method: method}, Extension: extension}
+	}
+	groups := []*RestRouteGroup{
+		{Module: "store", Service: "orders", Path: "/orders", Routes: []*RestRoute{
+			route("/orders", resources.HTTPMethodPost, Extension{Exposed: true, Protected: true}),
+		}},
+		{Module: "store", Service: "catalog", Path: "/items", Routes: []*RestRoute{
+			route("/items", resources.HTTPMethodPost, Extension{}),
+			route("/items", resources.HTTPMethodGet, Extension{Exposed: true}),
+		}},
+	}
+	want := []string{
+		"store/catalog:",
+		"  GET /items: public",
+		"  POST /items: hidden",
+		"store/orders:",
+		"  POST /orders: protected",
+	}
+	if got := routesSummary(sortedRoutes(groups)); got != strings.Join(want, "\n") {
+		t.Errorf("summary =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codefly-dev/core/wool"
+)
+
+// KrakenD editions
+const (
+	CommunityEdition  = "ce"
+	EnterpriseEdition = "ee"
+)
+
+// minimumVersions lists the extra_config namespaces that need a recent KrakenD
+var minimumVersions = map[string]string{
+	"telemetry/opentelemetry":          "2.6",
+	"telemetry/opentelemetry-security": "2.6",
+	"qos/ratelimit/proxy/redis":        "2.7",
+	"auth/api-keys":                    "2.1",
+	"backend/grpc":                     "2.2",
+	"security/policies":                "2.4",
+	"modifier/request-body-generator":  "2.7",
+	"modifier/response-body-generator": "2.7",
+}
+
+// enterpriseOnly lists the extra_config namespaces only available in KrakenD Enterprise
+var enterpriseOnly = map[string]bool{
+	"auth/api-keys":                    true,
+	"grpc":                             true,
+	"backend/grpc":                     true,
+	"server/static-filesystem":         true,
+	"telemetry/opentelemetry-security": true,
+	"qos/ratelimit/proxy/redis":        true,
+	"security/policies":                true,
+	"modifier/request-body-generator":  true,
+	"modifier/response-body-generator": true,
+}
+
+// parseVersion returns major and minor from a KrakenD tag like 2.6 or 2.6.3
+func parseVersion(tag string) (int, int, bool) {
+	tokens := strings.SplitN(strings.TrimPrefix(tag, "v"), ".", 3)
+	if len(tokens) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(tokens[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// olderThan is true when the version is known to be older than the required one
+func olderThan(version string, required string) bool {
+	major, minor, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	reqMajor, reqMinor, ok := parseVersion(required)
+	if !ok {
+		return false
+	}
+	if major != reqMajor {
+		return major < reqMajor
+	}
+	return minor < reqMinor
+}
+
+// CheckCompatibility fails on Enterprise only namespaces used with a Community image
+// and warns about namespaces not supported by the KrakenD version
+func (s *Service) CheckCompatibility(settings *KrakendSettings) error {
+	image := s.RuntimeImage()
+	edition := s.Edition()
+	var unsupported []string
+	check := func(extra map[string]any, where string) {
+		for namespace := range extra {
+			if enterpriseOnly[namespace] && edition != EnterpriseEdition {
+				unsupported = append(unsupported, fmt.Sprintf("%s (%s)", namespace, where))
+				continue
+			}
+			required, ok := minimumVersions[namespace]
+			if !ok || !olderThan(image.Tag, required) {
+				continue
+			}
+			s.Wool.Warn("KrakenD version too old for feature",
+				wool.Field("namespace", namespace),
+				wool.Field("version", image.Tag),
+				wool.Field("required", required),
+				wool.Field("where", where))
+		}
+	}
+	check(settings.ExtraConfig, "global")
+	for _, route := range settings.RESTGroup {
+		check(route.ExtraConfig, route.Endpoint)
+		check(route.Backend.ExtraConfig, fmt.Sprintf("%s backend", route.Endpoint))
+	}
+	for _, route := range settings.GRPCGroup {
+		check(route.ExtraConfig, route.Endpoint)
+		check(route.Backend.ExtraConfig, fmt.Sprintf("%s backend", route.Endpoint))
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return s.Wool.NewError("%s is the community edition of KrakenD, enterprise only features are used: %s",
+			image.FullName(), strings.Join(unsupported, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tcs := []struct {
+		tag   string
+		major int
+		minor int
+		ok    bool
+	}{
+		{"2.6", 2, 6, true},
+		{"2.6.3", 2, 6, true},
+		{"v2.7", 2, 7, true},
+		{"2.10-rc1", 2, 10, true},
+		{"latest", 0, 0, false},
+		{"2", 0, 0, false},
+		{"x.6", 0, 0, false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.tag, func(t *testing.T) {
+			major, minor, ok := parseVersion(tc.tag)
+			if major != tc.major || minor != tc.minor || ok != tc.ok {
+				t.Errorf("parseVersion(%q) = %d, %d, %v; want %d, %d, %v", tc.tag, major, minor, ok, tc.major, tc.minor, tc.ok)
+			}
+		})
+	}
+}
+
+func TestOlderThan(t *testing.T) {
+	tcs := []struct {
+		version  string
+		required string
+		older    bool
+	}{
+		{"2.6", "2.7", true},
+		{"2.7", "2.7", false},
+		{"2.7.1", "2.7", false},
+		{"2.10", "2.9", false},
+		{"1.4", "2.1", true},
+		{"3.0", "2.7", false},
+		{"latest", "2.7", false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.version+"<"+tc.required, func(t *testing.T) {
+			if got := olderThan(tc.version, tc.required); got != tc.older {
+				t.Errorf("olderThan(%q, %q) = %v; want %v", tc.version, tc.required, got, tc.older)
+			}
+		})
+	}
+}
+
+func TestEdition(t *testing.T) {
+	tcs := []struct {
+		settings Settings
+		edition  string
+	}{
+		{Settings{}, CommunityEdition},
+		{Settings{KrakendImage: "krakend/krakend-ee"}, EnterpriseEdition},
+		{Settings{KrakendImage: "registry.local/krakend-ee"}, EnterpriseEdition},
+		{Settings{KrakendImage: "registry.local/gateway", KrakendEdition: EnterpriseEdition}, EnterpriseEdition},
+	}
+	for _, tc := range tcs {
+		if got := tc.settings.Edition(); got != tc.edition {
+			t.Errorf("Edition() with image %q = %s; want %s", tc.settings.KrakendImage, got, tc.edition)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codefly-dev/core/agents/services"
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	builderv0 "github.com/codefly-dev/core/generated/go/codefly/services/builder/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/shared"
+	"github.com/codefly-dev/core/standards"
+)
+
+// ComposeDependency is a dependency of the gateway running next to it in docker compose
+type ComposeDependency struct {
+	Name  string
+	Image string
+	Ports []uint16
+}
+
+// ComposeParameters is used to template the docker-compose.yaml
+type ComposeParameters struct {
+	Name  string
+	Image string
+
+	// Paths are relative to the compose file
+	Routing  string
+	Settings string
+
+	// Mount is the location of the routing folder in the container
+	Mount string
+
+	Port     uint16
+	HostPort uint32
+
+	Envs    []*resources.EnvironmentVariable
+	Command []string
+
+	Dependencies []*ComposeDependency
+
+	// Collector is the compose service receiving the traces, empty without tracing
+	Collector string
+}
+
+// composeHost is the compose service name, hence the host name, of a dependency
+func composeHost(endpoint *basev0.Endpoint) string {
+	return shared.ToDNSCase(fmt.Sprintf("%s-%s", endpoint.Module, endpoint.Service))
+}
+
+// findWorkspace loads the workspace containing the service
+func (s *Builder) findWorkspace(ctx context.Context) (*resources.Workspace, error) {
+	dir := s.Location
+	for {
+		if resources.ExistsAtDir[resources.Workspace](dir) {
+			return resources.LoadWorkspaceFromDir(ctx, dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, s.Wool.NewError("cannot find workspace above %s", s.Location)
+		}
+		dir = parent
+	}
+}
+
+// dependencyImage is the image the builder of a dependency publishes
+func dependencyImage(ctx context.Context, workspace *resources.Workspace, repository string, endpoint *basev0.Endpoint) (*resources.DockerImage, error) {
+	mod, err := workspace.LoadModuleFromName(ctx, endpoint.Module)
+	if err != nil {
+		return nil, err
+	}
+	service, err := mod.LoadServiceFromName(ctx, endpoint.Service)
+	if err != nil {
+		return nil, err
+	}
+	return &resources.DockerImage{
+		Name: path.Join(repository, endpoint.Module, endpoint.Service),
+		Tag:  service.Version,
+	}, nil
+}
+
+// containerNetworkMappings points the endpoints to the host names given by host, on standard ports
+func containerNetworkMappings(ctx context.Context, endpoints []*basev0.Endpoint, host func(*basev0.Endpoint) string) []*basev0.NetworkMapping {
+	var results []*basev0.NetworkMapping
+	for _, endpoint := range endpoints {
+		hostname := host(endpoint)
+		port := standards.Port(endpoint.Api)
+		address := fmt.Sprintf("%s:%d", hostname, port)
+		if resources.IsRest(ctx, endpoint) != nil {
+			address = fmt.Sprintf("http://%s", address)
+		}
+		results = append(results, &basev0.NetworkMapping{
+			Endpoint: endpoint,
+			Instances: []*basev0.NetworkInstance{
+				{
+					Access:   resources.NewContainerNetworkAccess(),
+					Hostname: hostname,
+					Host:     fmt.Sprintf("%s:%d", hostname, port),
+					Port:     uint32(port),
+					Address:  address,
+				},
+			},
+		})
+	}
+	return results
+}
+
+// ComposeNetworkMappings re-targets the dependency network mappings to the compose service names
+func ComposeNetworkMappings(ctx context.Context, mappings []*basev0.NetworkMapping) []*basev0.NetworkMapping {
+	var endpoints []*basev0.Endpoint
+	for _, mapping := range mappings {
+		endpoints = append(endpoints, mapping.Endpoint)
+	}
+	return containerNetworkMappings(ctx, endpoints, composeHost)
+}
+
+// composeTarget is the destination of the compose file and the docker repository of the images:
+// taken from a Kubernetes deployment request if any, else the deployment folder of the service and local images
+func (s *Builder) composeTarget(req *builderv0.DeploymentRequest) (string, string) {
+	if k := req.Deployment.GetKubernetes(); k != nil {
+		return k.Destination, k.BuildContext.GetDockerRepository()
+	}
+	return s.Local("deployment/compose/%s", req.Environment.Name), ""
+}
+
+// ComposeDeploy writes a docker-compose.yaml running the gateway and its dependencies
+func (s *Builder) ComposeDeploy(ctx context.Context, req *builderv0.DeploymentRequest) error {
+	defer s.Wool.Catch()
+
+	destination, repository := s.composeTarget(req)
+
+	mappings := ComposeNetworkMappings(ctx, req.DependenciesNetworkMappings)
+
+	port := standards.Port(standards.REST)
+	s.docsRoot = path.Join(routingMount, "docs")
+	s.environment = req.Environment.Name
+	s.compose = true
+	err := s.environmentValidators(ctx, req.Environment.Name, req.Configuration)
+	if err != nil {
+		return err
+	}
+	conf, err := s.createConfig(ctx, mappings, resources.NewContainerNetworkAccess())
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create config")
+	}
+
+	err = shared.EmptyDir(ctx, destination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot empty destination")
+	}
+
+	settings := path.Join(destination, "settings/routing.json")
+	_, err = shared.CheckDirectoryOrCreate(ctx, path.Dir(settings))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create settings directory")
+	}
+	err = os.WriteFile(settings, conf, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write settings to %s", settings)
+	}
+
+	routing, err := filepath.Rel(destination, s.Local("routing"))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot find routing folder from destination")
+	}
+	if !strings.HasPrefix(routing, ".") {
+		// compose treats paths without a leading dot as named volumes
+		routing = "./" + routing
+	}
+
+	params := ComposeParameters{
+		Name:     s.Information.Service.Name.DNSCase,
+		Image:    s.RuntimeImage().FullName(),
+		Routing:  routing,
+		Settings: "./settings/routing.json",
+		Mount:    routingMount,
+		Port:     port,
+		HostPort: uint32(port),
+		Envs:     routingEnvironmentVariables(routingMount),
+		Command:  s.krakendCommand(routingMount),
+	}
+
+	if req.Configuration != nil {
+		// secrets are interpolated by docker compose from the environment, never written in the file
+		for _, secret := range resources.ConfigurationAsEnvironmentVariables(req.Configuration, true) {
+			params.Envs = append(params.Envs, resources.Env(secret.Key, fmt.Sprintf("${%s}", secret.Key)))
+		}
+	}
+
+	if s.Settings.Tracing != nil {
+		params.Collector = composeCollectorService
+	}
+
+	restEndpoint, err := resources.FindRestEndpoint(ctx, s.Endpoints)
+	if err == nil && restEndpoint != nil {
+		instance, err := resources.FindNetworkInstanceInNetworkMappings(ctx, req.NetworkMappings, restEndpoint, resources.NewNativeNetworkAccess())
+		if err == nil {
+			params.HostPort = instance.Port
+		}
+	}
+
+	var workspace *resources.Workspace
+	if len(mappings) > 0 {
+		workspace, err = s.findWorkspace(ctx)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot resolve dependency images")
+		}
+	}
+
+	dependencies := make(map[string]*ComposeDependency)
+	for _, mapping := range mappings {
+		host := composeHost(mapping.Endpoint)
+		dep, ok := dependencies[host]
+		if !ok {
+			image, err := dependencyImage(ctx, workspace, repository, mapping.Endpoint)
+			if err != nil {
+				return s.Wool.Wrapf(err, "cannot resolve image of %s/%s", mapping.Endpoint.Module, mapping.Endpoint.Service)
+			}
+			dep = &ComposeDependency{
+				Name:  host,
+				Image: image.FullName(),
+			}
+			dependencies[host] = dep
+			params.Dependencies = append(params.Dependencies, dep)
+		}
+		port := uint16(mapping.Instances[0].Port)
+		if !slices.Contains(dep.Ports, port) {
+			dep.Ports = append(dep.Ports, port)
+		}
+	}
+
+	err = s.Templates(ctx, params, services.WithDeployment(deploymentFS, "compose").WithDestination(destination))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot generate docker compose")
+	}
+	return nil
+}
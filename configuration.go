@@ -8,6 +8,8 @@ import (
 	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
 	"github.com/codefly-dev/core/wool"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/codefly-dev/core/agents/services"
 	"github.com/codefly-dev/core/resources"
@@ -19,8 +21,10 @@ import (
 type KrakendSettings struct {
 	Port      uint16               `json:"port"`
 	RESTGroup []ForwardedRESTRoute `json:"rest_group,omitempty"`
+	GRPCGroup []ForwardedGRPCRoute `json:"grpc_group,omitempty"`
 
 	ExtraConfig map[string]any `json:"extra_config,omitempty"`
+	APIKeys     *APIKeys       `json:"api_keys,omitempty"`
 }
 
 type ForwardedRESTRoute struct {
@@ -32,18 +36,22 @@ type ForwardedRESTRoute struct {
 }
 
 type ForwardedGRPCRoute struct {
-	Endpoint string  `json:"endpoint"`
-	Backend  Backend `json:"backend"`
+	Endpoint    string         `json:"endpoint"`
+	Backend     Backend        `json:"backend"`
+	ExtraConfig map[string]any `json:"extra_config,omitempty"`
 }
 
 type Backend struct {
-	URLPattern string   `json:"url_pattern"`
-	Hosts      []string `json:"hosts"`
+	URLPattern  string         `json:"url_pattern"`
+	Hosts       []string       `json:"hosts"`
+	ExtraConfig map[string]any `json:"extra_config,omitempty"`
 }
 
 type AuthValidator struct {
 	Key           string
 	Configuration any
+	// Global configuration of the validator, if any
+	Global any
 }
 
 // JWTAuthValidatorKey for auth
@@ -58,8 +66,39 @@ type JWTAuthValidator struct {
 	PropagateClaims [][]string `json:"propagate_claims,omitempty"`
 }
 
+// APIKeysKey for auth with API keys: KrakenD Enterprise only
+const APIKeysKey = "auth/api-keys"
+
+// APIKeyRole is given to all the API keys of the gateway
+const APIKeyRole = "gateway"
+
+// API keys are comma separated in the KEYS value of the api_keys secret configuration
+const (
+	APIKeysSecret    = "api_keys"
+	APIKeysSecretKey = "KEYS"
+)
+
+// APIKeys are rendered as auth/api-keys by the configuration template, with the keys read from Env when KrakenD starts
+type APIKeys struct {
+	Strategy   string   `json:"strategy"`
+	Identifier string   `json:"identifier"`
+	Roles      []string `json:"roles"`
+	Env        string   `json:"env"`
+}
+
+type APIKeysRoles struct {
+	Roles []string `json:"roles"`
+}
+
 type ModifierMartian struct {
-	HeaderCopy HeaderCopy `json:"header.Copy"`
+	HeaderCopy     *HeaderCopy     `json:"header.Copy,omitempty"`
+	HeaderModifier *HeaderModifier `json:"header.Modifier,omitempty"`
+}
+
+type HeaderModifier struct {
+	Scope []string `json:"scope"`
+	Name  string   `json:"name"`
+	Value string   `json:"value"`
 }
 
 type HeaderCopy struct {
@@ -77,7 +116,74 @@ type RegexModifier struct {
 
 const ModifierMartianKey = "modifier/martian"
 
+// RateLimitKey for rate limits of endpoints
+const RateLimitKey = "qos/ratelimit/router"
+
+type RateLimitConfig struct {
+	MaxRate       int `json:"max_rate,omitempty"`
+	ClientMaxRate int `json:"client_max_rate,omitempty"`
+}
+
+// String of the rate limit in requests per second
+func (r RateLimitConfig) String() string {
+	var parts []string
+	if r.MaxRate > 0 {
+		parts = append(parts, fmt.Sprintf("%d/s", r.MaxRate))
+	}
+	if r.ClientMaxRate > 0 {
+		parts = append(parts, fmt.Sprintf("%d/s per client", r.ClientMaxRate))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HTTPCacheKey for cache of backend responses
+const HTTPCacheKey = "qos/http-cache"
+
+type HTTPCacheConfig struct {
+	Shared bool `json:"shared"`
+}
+
+// String of the cache: shared between clients or not
+func (c HTTPCacheConfig) String() string {
+	if c.Shared {
+		return "shared"
+	}
+	return "per client"
+}
+
+// ApplyRouteExtension adds the extra configuration of the route file to its forwarding
+func ApplyRouteExtension(config *ForwardedRESTRoute, extension Extension) {
+	for key, value := range extension.ExtraConfig {
+		config.ExtraConfig[key] = value
+	}
+	if len(extension.BackendExtraConfig) > 0 && config.Backend.ExtraConfig == nil {
+		config.Backend.ExtraConfig = make(map[string]any)
+	}
+	for key, value := range extension.BackendExtraConfig {
+		config.Backend.ExtraConfig[key] = value
+	}
+}
+
+// decodeExtraConfig reads the namespace of an extra configuration into config: false when missing or invalid
+func decodeExtraConfig(extra map[string]any, key string, config any) bool {
+	value, ok := extra[key]
+	if !ok {
+		return false
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(content, config) == nil
+}
+
 func ProtectRestRoute(config *ForwardedRESTRoute, validators []*AuthValidator) error {
+	if len(validators) == 0 {
+		return fmt.Errorf("no authentication configured to protect %s %s", config.Method, config.Endpoint)
+	}
 	if config.ExtraConfig == nil {
 		config.ExtraConfig = make(map[string]any)
 	}
@@ -98,16 +204,23 @@ type CorsPolicy struct {
 
 const CorsPolicyKey = "security/cors"
 
-func Cors(key string) CorsPolicy {
+// Cors policy allowing the origins: all if empty or "*" alone
+func Cors(origins []string) (CorsPolicy, error) {
 	allowedHeaders := []string{"Content-Type", "Origin", "Authorization", "Accept"}
 	allowedHeaders = append(allowedHeaders, wool.Headers()...)
+	if len(origins) > 1 && slices.Contains(origins, "*") {
+		return CorsPolicy{}, fmt.Errorf("CORS origins cannot mix * with explicit origins: %s", strings.Join(origins, ", "))
+	}
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
 	return CorsPolicy{
-		AllowOrigins:  []string{"*"},
+		AllowOrigins:  origins,
 		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE"},
 		AllowHeaders:  allowedHeaders,
 		ExposeHeaders: []string{"Content-Length", "Content-Type"},
 		MaxAge:        "12h",
-	}
+	}, nil
 }
 
 func gatewayRestTarget(r *resources.RestRouteGroup) string {
@@ -127,16 +240,47 @@ func (s *Service) writeConfig(ctx context.Context, nms []*basev0.NetworkMapping,
 	return nil
 }
 
+// copyConfigTemplate writes the flexible configuration template in the routing folder
+func (s *Service) copyConfigTemplate() error {
+	err := shared.Embed(config).Copy("templates/krakend.config", s.Local("routing/config/krakend.tmpl"))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot copy config")
+	}
+	return nil
+}
+
 func (s *Service) createConfig(ctx context.Context, otherNetworkMappings []*basev0.NetworkMapping, networkAccess *basev0.NetworkAccess) ([]byte, error) {
 	// Write the main config
-	err := shared.Embed(config).Copy("templates/krakend.config", s.Local("routing/config/krakend.tmpl"))
+	err := s.copyConfigTemplate()
 	if err != nil {
-		return nil, s.Wool.Wrapf(err, "cannot copy config")
+		return nil, err
 	}
 
 	settings := KrakendSettings{Port: s.port, ExtraConfig: make(map[string]any)}
 	// setup CORS configuration globally
-	settings.ExtraConfig[CorsPolicyKey] = Cors(CorsPolicyKey)
+	cors, err := Cors(s.CorsOrigins)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "invalid cors-origins")
+	}
+	settings.ExtraConfig[CorsPolicyKey] = cors
+	err = s.serveDocs(&settings, s.docsRoot)
+	if err != nil {
+		return nil, err
+	}
+	err = s.telemetry(&settings)
+	if err != nil {
+		return nil, err
+	}
+	s.logging(&settings)
+	for _, validator := range s.validators {
+		switch global := validator.Global.(type) {
+		case nil:
+		case *APIKeys:
+			settings.APIKeys = global
+		default:
+			settings.ExtraConfig[validator.Key] = global
+		}
+	}
 
 	for _, group := range s.RestRouteGroups {
 		baseGroup := resources.UnwrapRestRouteGroup(group)
@@ -152,6 +296,8 @@ func (s *Service) createConfig(ctx context.Context, otherNetworkMappings []*base
 				continue
 			}
 			fwd := NewRESTForwarding(gatewayRestTarget(baseGroup), resources.UnwrapRestRoute(route), nm.Address)
+			fwd.InputHeaders = s.forwardedHeaders()
+			ApplyRouteExtension(&fwd, route.Extension)
 			if route.Extension.Protected {
 				// fwd.InputHeaders = wool.Headers()
 
@@ -163,6 +309,15 @@ func (s *Service) createConfig(ctx context.Context, otherNetworkMappings []*base
 			settings.RESTGroup = append(settings.RESTGroup, fwd)
 		}
 	}
+	err = s.forwardGRPC(ctx, &settings, otherNetworkMappings, networkAccess)
+	if err != nil {
+		return nil, err
+	}
+	err = s.CheckCompatibility(&settings)
+	if err != nil {
+		return nil, err
+	}
+
 	var content []byte
 	content, err = json.Marshal(settings)
 	if err != nil {
@@ -197,15 +352,27 @@ func (s *Service) writeOpenAPI(ctx context.Context, endpoints []*basev0.Endpoint
 			combinator.Only(baseGroup.ServiceUnique(), baseGroup.Path, string(route.Method))
 		}
 	}
-	restAPI, err := combinator.Combine(ctx)
-
+	_, err = combinator.Combine(ctx)
 	if err != nil {
 		return w.Wrapf(err, "cannot combine open api")
 	}
 
+	err = s.SecureOpenAPI(ctx)
+	if err != nil {
+		return w.Wrapf(err, "cannot add security to open api")
+	}
+
+	restAPI, err := resources.LoadRestAPI(ctx, shared.Pointer(s.openapiDestination))
+	if err != nil {
+		return w.Wrapf(err, "cannot load combined open api")
+	}
+
 	s.restEndpoint.ApiDetails = resources.ToRestAPI(restAPI)
 
 	s.Endpoints = []*basev0.Endpoint{s.restEndpoint}
+	if s.metricsEndpoint != nil {
+		s.Endpoints = append(s.Endpoints, s.metricsEndpoint)
+	}
 
 	return nil
 }
@@ -227,9 +394,11 @@ func NewGRPCForwarding(target string, base *resources.GRPCRoute, hosts []string)
 	return ForwardedGRPCRoute{
 		Endpoint: target,
 		Backend: Backend{
-			URLPattern: base.Route(),
-			Hosts:      hosts,
+			URLPattern:  base.Route(),
+			Hosts:       hosts,
+			ExtraConfig: map[string]any{GRPCBackendKey: map[string]any{}},
 		},
+		ExtraConfig: make(map[string]any),
 	}
 }
 
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/standards"
+)
+
+func TestCors(t *testing.T) {
+	tcs := []struct {
+		name    string
+		origins []string
+		allowed []string
+		fails   bool
+	}{
+		{"all by default", nil, []string{"*"}, false},
+		{"wildcard alone", []string{"*"}, []string{"*"}, false},
+		{"explicit origins", []string{"https://a.example.com", "https://b.example.com"}, []string{"https://a.example.com", "https://b.example.com"}, false},
+		{"wildcard mixed with an origin", []string{"*", "https://a.example.com"}, nil, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := Cors(tc.origins)
+			if (err != nil) != tc.fails {
+				t.Fatalf("Cors(%v) error = %v; want fails %v", tc.origins, err, tc.fails)
+			}
+			if !tc.fails && !reflect.DeepEqual(policy.AllowOrigins, tc.allowed) {
+				t.Errorf("Cors(%v) allows %v; want %v", tc.origins, policy.AllowOrigins, tc.allowed)
+			}
+		})
+	}
+}
+
+func protectedRouteService(t *testing.T) (*Service, []*basev0.NetworkMapping) {
+	s := NewService()
+	s.Location = t.TempDir()
+	s.Base.Service = &resources.Service{Name: "gateway"}
+	if err := os.MkdirAll(s.Local("routing/config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	route := &RestRoute{
+		RestRoute: resources.RestRoute{Path: "/items", Method: resources.HTTPMethodGet},
+		Extension: Extension{Exposed: true, Protected: true},
+	}
+	s.RestRouteGroups = []*RestRouteGroup{{Path: "/items", Module: "store", Service: "catalog", Routes: []*RestRoute{route}}}
+	endpoint := &basev0.Endpoint{Module: "store", Service: "catalog", Name: standards.REST, Api: standards.REST,
+		ApiDetails: resources.ToRestAPI(&basev0.RestAPI{})}
+	return s, containerNetworkMappings(context.Background(), []*basev0.Endpoint{endpoint}, func(*basev0.Endpoint) string { return "catalog" })
+}
+
+func TestCreateConfigRefusesUnprotectedRoutes(t *testing.T) {
+	s, mappings := protectedRouteService(t)
+	_, err := s.createConfig(context.Background(), mappings, resources.NewContainerNetworkAccess())
+	if err == nil || !strings.Contains(err.Error(), "no authentication configured") {
+		t.Fatalf("createConfig() error = %v; want a protected route without validators refused", err)
+	}
+
+	s.validators = []*AuthValidator{{Key: JWTAuthValidatorKey, Configuration: JWTAuthValidator{Alg: "RS256"}}}
+	content, err := s.createConfig(context.Background(), mappings, resources.NewContainerNetworkAccess())
+	if err != nil {
+		t.Fatalf("createConfig() error = %v", err)
+	}
+	if !strings.Contains(string(content), JWTAuthValidatorKey) {
+		t.Errorf("createConfig() = %s; want the route protected by %s", content, JWTAuthValidatorKey)
+	}
+}
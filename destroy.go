@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	runtimev0 "github.com/codefly-dev/core/generated/go/codefly/services/runtime/v0"
+	runners "github.com/codefly-dev/core/runners/base"
+	"github.com/codefly-dev/core/wool"
+)
+
+// generatedArtifacts regenerated when needed: the build combines the OpenAPI again and the configuration template is copied back
+func (s *Service) generatedArtifacts() []string {
+	return []string{
+		s.renderedConfigLocation(),
+		s.Local("routing/config/out.json"),
+		s.Local("routing/config/settings/routing.json"),
+		s.Local("routing/config/krakend.tmpl"),
+		s.openapiDestination,
+	}
+}
+
+// removeGeneratedArtifacts deletes the generated files and returns the removed ones, relative to the service
+func (s *Service) removeGeneratedArtifacts() ([]string, error) {
+	var removed []string
+	for _, artifact := range s.generatedArtifacts() {
+		if _, err := os.Stat(artifact); os.IsNotExist(err) {
+			continue
+		}
+		err := os.RemoveAll(artifact)
+		if err != nil {
+			return removed, s.Wool.Wrapf(err, "cannot remove %s", artifact)
+		}
+		if rel, err := filepath.Rel(s.Local(""), artifact); err == nil {
+			artifact = rel
+		}
+		removed = append(removed, artifact)
+	}
+	return removed, nil
+}
+
+// removeContainer stops and removes the gateway container, even when started by another agent
+func (s *Runtime) removeContainer(ctx context.Context) (bool, error) {
+	runner := s.runner
+	if runner == nil {
+		var err error
+		runner, err = runners.NewDockerHeadlessEnvironment(ctx, s.RuntimeImage(), s.UniqueWithWorkspace())
+		if err != nil {
+			return false, s.Wool.Wrapf(err, "cannot create docker environment")
+		}
+	}
+	present, err := runner.IsContainerPresent(ctx)
+	if err != nil {
+		return false, s.Wool.Wrapf(err, "cannot check gateway container")
+	}
+	if !present {
+		return false, nil
+	}
+	err = runner.Shutdown(ctx)
+	if err != nil {
+		return false, s.Wool.Wrapf(err, "cannot remove gateway container")
+	}
+	s.runner = nil
+	return true, nil
+}
+
+// destroyResponse reports what was removed
+func (s *Runtime) destroyResponse(removed []string) (*runtimev0.DestroyResponse, error) {
+	message := "nothing to remove"
+	if len(removed) > 0 {
+		message = "removed: " + strings.Join(removed, ", ")
+	}
+	s.Wool.Info(message)
+	s.Runtime.DestroyStatus = &runtimev0.DestroyStatus{State: runtimev0.DestroyStatus_SUCCESS, Message: message}
+	return &runtimev0.DestroyResponse{Status: s.Runtime.DestroyStatus}, nil
+}
+
+// destroy the gateway and its generated files
+func (s *Runtime) destroy(ctx context.Context) ([]string, error) {
+	var removed []string
+
+	err := s.stopNative(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.proc = nil
+	s.live = nil
+
+	if !s.native {
+		container, err := s.removeContainer(ctx)
+		if err != nil {
+			s.Wool.Warn("cannot remove gateway container", wool.ErrField(err))
+		}
+		if container {
+			removed = append(removed, "container "+s.UniqueWithWorkspace())
+		}
+	}
+
+	files, err := s.removeGeneratedArtifacts()
+	return append(removed, files...), err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemoveGeneratedArtifacts(t *testing.T) {
+	s := NewService()
+	s.Location = t.TempDir()
+	s.openapiDestination = s.Local("openapi/api.swagger.json")
+
+	want := []string{
+		"routing/out.json",
+		"routing/config/out.json",
+		"routing/config/settings/routing.json",
+		"routing/config/krakend.tmpl",
+		"openapi/api.swagger.json",
+	}
+	// kept: the route files and the published reference of the breaking changes check
+	kept := []string{
+		"routing/rest/store/catalog/items.rest.codefly.yaml",
+		publishedOpenAPILocation,
+	}
+	for _, f := range append(append([]string{}, want...), kept...) {
+		err := os.MkdirAll(filepath.Dir(s.Local(f)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(s.Local(f), []byte("{}"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := s.removeGeneratedArtifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v; want %v", removed, want)
+	}
+	for _, f := range kept {
+		if _, err := os.Stat(s.Local(f)); err != nil {
+			t.Errorf("%s should be kept: %v", f, err)
+		}
+	}
+
+	removed, err = s.removeGeneratedArtifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("removed again = %v; want nothing", removed)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/codefly-dev/core/shared"
+)
+
+// Docs UI served next to the combined OpenAPI
+const (
+	SwaggerDocs = "swagger"
+	RedocDocs   = "redoc"
+)
+
+// docsPrefix is the gateway path of the combined OpenAPI and docs UI
+const docsPrefix = "/docs/"
+
+// StaticFilesystemKey for serving files from the gateway
+const StaticFilesystemKey = "server/static-filesystem"
+
+type StaticFilesystem struct {
+	Prefix string `json:"prefix"`
+	Path   string `json:"path"`
+}
+
+// imageDocs is where the built image keeps the docs folder
+const imageDocs = "/app/docs"
+
+// docsFolder is the docs folder of the routing, served by the gateway
+func (s *Service) docsFolder() string {
+	return s.Local("routing/docs")
+}
+
+// checkDocsEdition fails on the community edition: it ignores static file serving and the docs would not be found
+func (s *Service) checkDocsEdition() error {
+	if s.Settings.Edition() != EnterpriseEdition {
+		return s.Wool.NewError("serve-openapi requires KrakenD Enterprise (%s): use a krakend-ee image or set krakend-edition: ee", StaticFilesystemKey)
+	}
+	return nil
+}
+
+// WriteDocs copies the combined OpenAPI and the docs UI to the routing docs folder
+func (s *Service) WriteDocs(ctx context.Context) error {
+	if !s.Settings.ServeOpenAPI {
+		return nil
+	}
+	err := s.checkDocsEdition()
+	if err != nil {
+		return err
+	}
+	exists, err := shared.FileExists(ctx, s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot check combined openapi")
+	}
+	if !exists {
+		return s.Wool.NewError("no combined openapi to serve: run the gateway once to generate it")
+	}
+	_, err = shared.CheckDirectoryOrCreate(ctx, s.docsFolder())
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create docs folder")
+	}
+	content, err := os.ReadFile(s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot read combined openapi")
+	}
+	err = os.WriteFile(path.Join(s.docsFolder(), "openapi.json"), content, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write served openapi")
+	}
+
+	index := path.Join(s.docsFolder(), "index.html")
+	switch s.Settings.DocsUI {
+	case "":
+		err = os.Remove(index)
+		if err != nil && !os.IsNotExist(err) {
+			return s.Wool.Wrapf(err, "cannot remove docs UI")
+		}
+		return nil
+	case SwaggerDocs, RedocDocs:
+		err = shared.Embed(docsFS).Copy(fmt.Sprintf("templates/docs/%s.html", s.Settings.DocsUI), index)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot copy docs UI")
+		}
+		return nil
+	default:
+		return s.Wool.NewError("unknown docs UI: %s", s.Settings.DocsUI)
+	}
+}
+
+// serveDocs adds the static serving of the docs folder, found at root in the gateway
+func (s *Service) serveDocs(settings *KrakendSettings, root string) error {
+	if !s.Settings.ServeOpenAPI {
+		return nil
+	}
+	err := s.checkDocsEdition()
+	if err != nil {
+		return err
+	}
+	settings.ExtraConfig[StaticFilesystemKey] = StaticFilesystem{Prefix: docsPrefix, Path: root}
+	return nil
+}
+
+//go:embed templates/docs
+var docsFS embed.FS
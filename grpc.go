@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/wool"
+)
+
+// GRPCCatalogKey lists the folders of the protobuf descriptors used by the gRPC backends: KrakenD Enterprise only
+const GRPCCatalogKey = "grpc"
+
+// GRPCBackendKey makes a backend call its host with gRPC
+const GRPCBackendKey = "backend/grpc"
+
+type GRPCCatalog struct {
+	Catalog []string `json:"catalog"`
+}
+
+// grpcDescriptors is the folder of the protobuf descriptors of the forwarded services
+const grpcDescriptors = "routing/grpc"
+
+// grpcRoot is routing/grpc as seen by the gateway, next to the docs folder
+func (s *Service) grpcRoot() string {
+	return path.Join(path.Dir(s.docsRoot), "grpc")
+}
+
+// protectsGRPC is true when the forwarded RPCs need the authentication of the auth mode
+func (s *Service) protectsGRPC() bool {
+	return s.GRPCForwarding && s.AuthMode != "" && s.AuthMode != NoAuth
+}
+
+func gatewayGRPCTarget(route *resources.GRPCRoute) string {
+	return fmt.Sprintf("/%s/%s%s", route.Module, route.Service, route.Route())
+}
+
+func ProtectGRPCRoute(config *ForwardedGRPCRoute, validators []*AuthValidator) error {
+	if len(validators) == 0 {
+		return fmt.Errorf("no authentication configured to protect %s", config.Endpoint)
+	}
+	for _, validator := range validators {
+		config.ExtraConfig[validator.Key] = validator.Configuration
+	}
+	return nil
+}
+
+// forwardGRPC exposes all the RPCs of the gRPC dependencies when gRPC forwarding is enabled
+func (s *Service) forwardGRPC(ctx context.Context, settings *KrakendSettings, mappings []*basev0.NetworkMapping, networkAccess *basev0.NetworkAccess) error {
+	if !s.GRPCForwarding {
+		return nil
+	}
+	for _, mapping := range mappings {
+		api := resources.IsGRPC(ctx, mapping.Endpoint)
+		if api == nil {
+			continue
+		}
+		var instance *basev0.NetworkInstance
+		for _, inst := range mapping.Instances {
+			if inst.Access.Kind == networkAccess.Kind {
+				instance = inst
+				break
+			}
+		}
+		if instance == nil {
+			return s.Wool.NewError("cannot find network mapping for gRPC endpoint %s/%s", mapping.Endpoint.Module, mapping.Endpoint.Service)
+		}
+		for _, rpc := range api.Rpcs {
+			route := resources.GRPCRouteFromProto(mapping.Endpoint, api, rpc)
+			fwd := NewGRPCForwarding(gatewayGRPCTarget(route), route, []string{instance.Host})
+			if s.protectsGRPC() {
+				err := ProtectGRPCRoute(&fwd, s.validators)
+				if err != nil {
+					return s.Wool.Wrapf(err, "cannot create protected route without validator")
+				}
+			}
+			s.Wool.Debug("forwarding RPC", wool.Field("endpoint", fwd.Endpoint))
+			settings.GRPCGroup = append(settings.GRPCGroup, fwd)
+		}
+	}
+	if len(settings.GRPCGroup) > 0 {
+		settings.ExtraConfig[GRPCCatalogKey] = GRPCCatalog{Catalog: []string{s.grpcRoot()}}
+	}
+	return nil
+}
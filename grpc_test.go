@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/standards"
+)
+
+func grpcMapping() *basev0.NetworkMapping {
+	return &basev0.NetworkMapping{
+		Endpoint: &basev0.Endpoint{
+			Module:  "store",
+			Service: "catalog",
+			Api:     standards.GRPC,
+			ApiDetails: &basev0.API{Value: &basev0.API_Grpc{Grpc: &basev0.GrpcAPI{
+				Package: "catalog.v1",
+				Rpcs:    []*basev0.RPC{{Name: "GetItem", ServiceName: "Catalog"}},
+			}}},
+		},
+		Instances: []*basev0.NetworkInstance{
+			{Access: resources.NewContainerNetworkAccess(), Host: "store-catalog:9090"},
+		},
+	}
+}
+
+func TestForwardGRPC(t *testing.T) {
+	ctx := context.Background()
+	tcs := []struct {
+		name       string
+		forwarding bool
+		authMode   string
+		validators []*AuthValidator
+		endpoints  []string
+		protected  bool
+		fails      bool
+	}{
+		{"disabled", false, NoAuth, nil, nil, false, false},
+		{"public", true, NoAuth, nil, []string{"/store/catalog/catalog.v1.Catalog/GetItem"}, false, false},
+		{"protected", true, JWTAuth, []*AuthValidator{{Key: JWTAuthValidatorKey, Configuration: JWTAuthValidator{}}}, []string{"/store/catalog/catalog.v1.Catalog/GetItem"}, true, false},
+		{"protected without validators", true, JWTAuth, nil, nil, false, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService()
+			s.docsRoot = "/routing/docs"
+			s.GRPCForwarding = tc.forwarding
+			s.AuthMode = tc.authMode
+			s.validators = tc.validators
+			settings := KrakendSettings{ExtraConfig: make(map[string]any)}
+			err := s.forwardGRPC(ctx, &settings, []*basev0.NetworkMapping{grpcMapping()}, resources.NewContainerNetworkAccess())
+			if tc.fails {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var endpoints []string
+			for _, route := range settings.GRPCGroup {
+				endpoints = append(endpoints, route.Endpoint)
+				if !reflect.DeepEqual(route.Backend.Hosts, []string{"store-catalog:9090"}) {
+					t.Errorf("hosts = %v", route.Backend.Hosts)
+				}
+				if _, ok := route.ExtraConfig[JWTAuthValidatorKey]; ok != tc.protected {
+					t.Errorf("protected = %v; want %v", ok, tc.protected)
+				}
+			}
+			if !reflect.DeepEqual(endpoints, tc.endpoints) {
+				t.Errorf("endpoints = %v; want %v", endpoints, tc.endpoints)
+			}
+			if _, ok := settings.ExtraConfig[GRPCCatalogKey]; ok != (len(tc.endpoints) > 0) {
+				t.Errorf("catalog set = %v; want %v", ok, len(tc.endpoints) > 0)
+			}
+		})
+	}
+}
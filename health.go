@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// healthPath is the KrakenD health endpoint
+const healthPath = "/__health"
+
+// Readiness of the gateway
+const (
+	readinessTimeout  = 30 * time.Second
+	readinessInterval = 200 * time.Millisecond
+)
+
+// healthURL of the gateway from the host
+func healthURL(port uint16) string {
+	return fmt.Sprintf("http://localhost:%d%s", port, healthPath)
+}
+
+// WaitForReady polls the health endpoint of the gateway until it answers or the timeout expires
+func (s *Runtime) WaitForReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: readinessInterval * 5}
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+
+	var last error
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.healthURL, nil)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot create health request")
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		last = err
+		select {
+		case <-ctx.Done():
+			return s.startupFailure(last)
+		case <-ticker.C:
+		}
+	}
+}
+
+// startupFailure reports the last gateway logs
+func (s *Runtime) startupFailure(err error) error {
+	var logs []string
+	if s.logs != nil {
+		logs = s.logs.Last()
+	}
+	if len(logs) == 0 {
+		return s.Wool.Wrapf(err, "gateway not ready after %s on %s", readinessTimeout, s.healthURL)
+	}
+	return s.Wool.Wrapf(err, "gateway not ready after %s on %s, last logs:\n%s", readinessTimeout, s.healthURL, strings.Join(logs, "\n"))
+}
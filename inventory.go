@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/codefly-dev/core/agents/services"
+	"github.com/codefly-dev/core/resources"
+)
+
+// InventoryRoute is a gateway endpoint as listed in the routing README
+type InventoryRoute struct {
+	Endpoint string
+	Method   string
+	Backend  string
+	Exposure Exposure
+	Auth     string
+	// RateLimit and Cache from the extra configuration of the route: - without
+	RateLimit string
+	Cache     string
+}
+
+// RouteInventory is used to template the routing README
+type RouteInventory struct {
+	Service string
+	Routes  []*InventoryRoute
+	Exposed int
+}
+
+func (s *Service) authFor(extension Extension) string {
+	if !extension.Exposed || !extension.Protected {
+		return NoAuth
+	}
+	if s.Settings.AuthMode == "" || s.Settings.AuthMode == NoAuth {
+		return "protected"
+	}
+	return s.Settings.AuthMode
+}
+
+func rateLimitSummary(extension Extension) string {
+	var limit RateLimitConfig
+	if !decodeExtraConfig(extension.ExtraConfig, RateLimitKey, &limit) {
+		return "-"
+	}
+	return limit.String()
+}
+
+func cacheSummary(extension Extension) string {
+	var cache HTTPCacheConfig
+	if !decodeExtraConfig(extension.BackendExtraConfig, HTTPCacheKey, &cache) {
+		return "-"
+	}
+	return cache.String()
+}
+
+// Inventory of all the known routes, exposed or not, sorted by endpoint
+func (s *Service) Inventory() *RouteInventory {
+	inventory := &RouteInventory{Service: s.Base.Service.Name}
+	for _, group := range s.RestRouteGroups {
+		baseGroup := resources.UnwrapRestRouteGroup(group)
+		for _, route := range group.Routes {
+			exposure := exposureOf(route)
+			if exposure != ExposureHidden {
+				inventory.Exposed++
+			}
+			inventory.Routes = append(inventory.Routes, &InventoryRoute{
+				Endpoint:  gatewayRestTarget(baseGroup),
+				Method:    string(route.Method),
+				Backend:   fmt.Sprintf("%s %s", baseGroup.ServiceUnique(), route.Path),
+				Exposure:  exposure,
+				Auth:      s.authFor(route.Extension),
+				RateLimit: rateLimitSummary(route.Extension),
+				Cache:     cacheSummary(route.Extension),
+			})
+		}
+	}
+	sort.Slice(inventory.Routes, func(i, j int) bool {
+		if inventory.Routes[i].Endpoint != inventory.Routes[j].Endpoint {
+			return inventory.Routes[i].Endpoint < inventory.Routes[j].Endpoint
+		}
+		return inventory.Routes[i].Method < inventory.Routes[j].Method
+	})
+	return inventory
+}
+
+// WriteRouteInventory renders the routing README from the known routes
+func (s *Service) WriteRouteInventory(ctx context.Context) error {
+	err := s.Templates(ctx, s.Inventory(), services.WithTemplate(routingFS, "routing", "routing"))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write route inventory")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRouteSummaries(t *testing.T) {
+	tcs := []struct {
+		name      string
+		extension Extension
+		rateLimit string
+		cache     string
+	}{
+		{"none", Extension{}, "-", "-"},
+		{"rate limits", Extension{ExtraConfig: map[string]any{
+			RateLimitKey: map[string]any{"max_rate": 50, "client_max_rate": 5},
+		}}, "50/s, 5/s per client", "-"},
+		{"shared cache", Extension{BackendExtraConfig: map[string]any{
+			HTTPCacheKey: map[string]any{"shared": true},
+		}}, "-", "shared"},
+		{"cache per client", Extension{BackendExtraConfig: map[string]any{
+			HTTPCacheKey: map[string]any{},
+		}}, "-", "per client"},
+		{"invalid rate limit", Extension{ExtraConfig: map[string]any{
+			RateLimitKey: "fast",
+		}}, "-", "-"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rateLimitSummary(tc.extension); got != tc.rateLimit {
+				t.Errorf("rate limit = %q; want %q", got, tc.rateLimit)
+			}
+			if got := cacheSummary(tc.extension); got != tc.cache {
+				t.Errorf("cache = %q; want %q", got, tc.cache)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	runtimev0 "github.com/codefly-dev/core/generated/go/codefly/services/runtime/v0"
+	"github.com/codefly-dev/core/wool"
+)
+
+// protectionKeys in the endpoint extra config of protected routes
+var protectionKeys = []string{JWTAuthValidatorKey, APIKeysKey, ModifierMartianKey}
+
+// LiveRoute is an endpoint served by the running gateway
+type LiveRoute struct {
+	Endpoint  string
+	Method    string
+	Backend   string
+	Protected bool
+	RateLimit string
+}
+
+// LiveRouteTable of the running gateway and the hash of its configuration
+type LiveRouteTable struct {
+	Hash   string
+	Routes []*LiveRoute
+}
+
+// renderedConfig is the part of the rendered KrakenD configuration listing the routes
+type renderedConfig struct {
+	Endpoints []struct {
+		Endpoint string `json:"endpoint"`
+		Method   string `json:"method"`
+		Backend  []struct {
+			URLPattern string   `json:"url_pattern"`
+			Host       []string `json:"host"`
+		} `json:"backend"`
+		ExtraConfig map[string]json.RawMessage `json:"extra_config"`
+	} `json:"endpoints"`
+}
+
+// renderedConfigLocation is where the flexible configuration writes the configuration it runs (FC_OUT)
+func (s *Service) renderedConfigLocation() string {
+	return s.Local("routing/out.json")
+}
+
+// LoadLiveRoutes from the configuration rendered by the running gateway
+func (s *Service) LoadLiveRoutes() (*LiveRouteTable, error) {
+	content, err := os.ReadFile(s.renderedConfigLocation())
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot read rendered configuration")
+	}
+	var config renderedConfig
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot parse rendered configuration")
+	}
+	sum := sha256.Sum256(content)
+	table := &LiveRouteTable{Hash: hex.EncodeToString(sum[:])}
+	for _, endpoint := range config.Endpoints {
+		route := &LiveRoute{Endpoint: endpoint.Endpoint, Method: endpoint.Method, RateLimit: "-"}
+		var backends []string
+		for _, backend := range endpoint.Backend {
+			backends = append(backends, strings.Join(backend.Host, ",")+backend.URLPattern)
+		}
+		route.Backend = strings.Join(backends, " ")
+		for _, key := range protectionKeys {
+			if _, ok := endpoint.ExtraConfig[key]; ok {
+				route.Protected = true
+			}
+		}
+		if raw, ok := endpoint.ExtraConfig[RateLimitKey]; ok {
+			var limit RateLimitConfig
+			if json.Unmarshal(raw, &limit) == nil {
+				route.RateLimit = limit.String()
+			}
+		}
+		table.Routes = append(table.Routes, route)
+	}
+	return table, nil
+}
+
+// String of the table: one route per line after the configuration hash
+func (t *LiveRouteTable) String() string {
+	lines := []string{fmt.Sprintf("config sha256:%s", t.Hash)}
+	for _, route := range t.Routes {
+		protection := "public"
+		if route.Protected {
+			protection = "protected"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s -> %s (%s, rate limit: %s)", route.Method, route.Endpoint, route.Backend, protection, route.RateLimit))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withLiveRoutes adds the route table of the running gateway to the start status
+func (s *Runtime) withLiveRoutes(resp *runtimev0.InformationResponse) *runtimev0.InformationResponse {
+	if resp.StartStatus == nil || resp.StartStatus.State != runtimev0.StartStatus_STARTED {
+		return resp
+	}
+	live, err := s.LoadLiveRoutes()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.Wool.Debug("no rendered configuration yet", wool.ErrField(err))
+	case err != nil:
+		s.Wool.Warn("cannot reload live routes", wool.ErrField(err))
+	default:
+		s.live = live
+	}
+	if s.live == nil {
+		return resp
+	}
+	resp.StartStatus = &runtimev0.StartStatus{State: resp.StartStatus.State, Message: s.live.String()}
+	return resp
+}
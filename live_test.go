@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLoadLiveRoutes(t *testing.T) {
+	s := NewService()
+	s.Location = t.TempDir()
+
+	_, err := s.LoadLiveRoutes()
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("error = %v; want a missing rendered configuration", err)
+	}
+
+	err = os.MkdirAll(s.Local("routing"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := `{"endpoints": [{
+		"endpoint": "/store/catalog/items",
+		"method": "GET",
+		"backend": [{"url_pattern": "/items", "host": ["http://store-catalog:8080"]}],
+		"extra_config": {"auth/validator": {}, "qos/ratelimit/router": {"max_rate": 50}}
+	}, {
+		"endpoint": "/store/catalog/health",
+		"method": "GET",
+		"backend": [{"url_pattern": "/health", "host": ["http://store-catalog:8080"]}]
+	}]}`
+	err = os.WriteFile(s.renderedConfigLocation(), []byte(rendered), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := s.LoadLiveRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LiveRoute{
+		{Endpoint: "/store/catalog/items", Method: "GET", Backend: "http://store-catalog:8080/items", Protected: true, RateLimit: "50/s"},
+		{Endpoint: "/store/catalog/health", Method: "GET", Backend: "http://store-catalog:8080/health", RateLimit: "-"},
+	}
+	if len(table.Routes) != len(want) {
+		t.Fatalf("routes = %d; want %d", len(table.Routes), len(want))
+	}
+	for i, route := range table.Routes {
+		if *route != want[i] {
+			t.Errorf("route %d = %+v; want %+v", i, *route, want[i])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codefly-dev/core/wool"
+)
+
+// LoggingKey for the gateway logs
+const LoggingKey = "telemetry/logging"
+
+// LogstashKey enables the JSON log format
+const LogstashKey = "telemetry/logstash"
+
+// RouterKey for the router options, access log included
+const RouterKey = "router"
+
+// Logging of the gateway
+type Logging struct {
+	// Level: DEBUG, INFO (default), WARNING, ERROR or CRITICAL
+	Level string `yaml:"level,omitempty"`
+	// JSON logs instead of text
+	JSON bool `yaml:"json,omitempty"`
+	// Syslog output in addition to stdout
+	Syslog bool `yaml:"syslog,omitempty"`
+	// DisableAccessLog of the router
+	DisableAccessLog bool `yaml:"disable-access-log,omitempty"`
+	// AccessLogFormat of the router: includes the user auth ID if not set
+	AccessLogFormat string `yaml:"access-log-format,omitempty"`
+}
+
+type LoggingConfig struct {
+	Level  string `json:"level"`
+	Prefix string `json:"prefix"`
+	Syslog bool   `json:"syslog"`
+	Stdout bool   `json:"stdout"`
+	Format string `json:"format"`
+}
+
+type LogstashConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+type RouterConfig struct {
+	DisableAccessLog bool   `json:"disable_access_log,omitempty"`
+	AccessLogFormat  string `json:"access_log_format,omitempty"`
+}
+
+// level with default
+func (l *Logging) level() string {
+	if l.Level == "" {
+		return "INFO"
+	}
+	return strings.ToUpper(l.Level)
+}
+
+// Debug is true when the gateway runs in debug mode: always without logging settings
+func (l *Logging) Debug() bool {
+	return l == nil || l.level() == "DEBUG"
+}
+
+// defaultAccessLogFormat adds the user auth ID to the usual access log fields
+func defaultAccessLogFormat() string {
+	return fmt.Sprintf("$time_local $remote_addr $method $path $status $latency user=$header:%s", wool.Header(wool.UserAuthIDKey))
+}
+
+// logging adds the logging configuration from settings
+func (s *Service) logging(settings *KrakendSettings) {
+	l := s.Settings.Logging
+	if l == nil {
+		return
+	}
+	config := LoggingConfig{
+		Level:  l.level(),
+		Prefix: fmt.Sprintf("[%s]", strings.ToUpper(s.Base.Service.Name)),
+		Syslog: l.Syslog,
+		Stdout: true,
+		Format: "default",
+	}
+	if l.JSON {
+		config.Format = "logstash"
+		settings.ExtraConfig[LogstashKey] = LogstashConfig{Enabled: true}
+	}
+	settings.ExtraConfig[LoggingKey] = config
+
+	router := RouterConfig{DisableAccessLog: l.DisableAccessLog}
+	if !l.DisableAccessLog {
+		router.AccessLogFormat = l.AccessLogFormat
+		if router.AccessLogFormat == "" {
+			router.AccessLogFormat = defaultAccessLogFormat()
+		}
+	}
+	settings.ExtraConfig[RouterKey] = router
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codefly-dev/core/wool"
+)
+
+// KrakenD log lines look like:
+// [KRAKEND] 2024/05/01 - 10:00:00.000 ▶ ERROR [ENDPOINT: /module/service/items] Error #01: invalid token
+// and access logs like:
+// [GIN] 2024/05/01 - 10:00:00 | 401 |  1.2ms | 172.17.0.1 | GET      "/module/service/items"
+var (
+	logLevel   = regexp.MustCompile(`▶\s+(DEBUG|INFO|WARNING|ERROR|CRITICAL)\s*(.*)$`)
+	logModule  = regexp.MustCompile(`^\[([A-Z][A-Z ]*?)(?::\s*([^\]]+))?\]\s*(.*)$`)
+	accessLog  = regexp.MustCompile(`\|\s*(\d{3})\s*\|\s*([^|]+?)\s*\|\s*([^|]+?)\s*\|\s*([A-Z]+)\s+"([^"]+)"`)
+	jsonFields = []string{"msg", "message"}
+)
+
+// KrakendLog is a parsed log line of the gateway
+type KrakendLog struct {
+	Level   string
+	Module  string
+	Route   string
+	Status  int
+	Message string
+}
+
+// ParseKrakendLog extracts level, module and route from a gateway log line
+func ParseKrakendLog(line string) *KrakendLog {
+	line = strings.TrimSpace(line)
+	log := &KrakendLog{Level: "INFO", Message: line}
+
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(line), &fields); err == nil {
+			if level, ok := fields["level"].(string); ok {
+				log.Level = strings.ToUpper(level)
+			}
+			for _, key := range jsonFields {
+				if message, ok := fields[key].(string); ok {
+					log.Message = message
+					break
+				}
+			}
+			parseModule(log, log.Message)
+			return log
+		}
+	}
+
+	if match := accessLog.FindStringSubmatch(line); match != nil {
+		log.Module = "ACCESS"
+		log.Status, _ = strconv.Atoi(match[1])
+		log.Route = match[5]
+		log.Message = strings.Join([]string{match[4], match[5], match[1], match[2]}, " ")
+		switch {
+		case log.Status >= 500:
+			log.Level = "ERROR"
+		case log.Status >= 400:
+			log.Level = "WARNING"
+		default:
+			log.Level = "DEBUG"
+		}
+		return log
+	}
+
+	if match := logLevel.FindStringSubmatch(line); match != nil {
+		log.Level = match[1]
+		log.Message = match[2]
+		parseModule(log, match[2])
+	}
+	return log
+}
+
+func parseModule(log *KrakendLog, message string) {
+	match := logModule.FindStringSubmatch(message)
+	if match == nil {
+		return
+	}
+	log.Module = match[1]
+	log.Message = match[3]
+	if detail := strings.TrimSpace(match[2]); strings.HasPrefix(detail, "/") {
+		log.Route = detail
+	} else if detail != "" {
+		log.Module = strings.Join([]string{log.Module, detail}, " ")
+	}
+}
+
+// Fields of the log for wool
+func (log *KrakendLog) Fields() []*wool.LogField {
+	var fields []*wool.LogField
+	if log.Module != "" {
+		fields = append(fields, wool.Field("module", log.Module))
+	}
+	if log.Route != "" {
+		fields = append(fields, wool.Field("route", log.Route))
+	}
+	if log.Status != 0 {
+		fields = append(fields, wool.Field("status", log.Status))
+	}
+	return fields
+}
+
+// keptLogLines are reported when the gateway fails to start
+const keptLogLines = 20
+
+// KrakendLogWriter forwards the gateway output as structured wool logs
+type KrakendLogWriter struct {
+	w *wool.Wool
+
+	sync.Mutex
+	last []string
+}
+
+func NewKrakendLogWriter(w *wool.Wool) *KrakendLogWriter {
+	return &KrakendLogWriter{w: w}
+}
+
+// Last lines of the gateway output
+func (k *KrakendLogWriter) Last() []string {
+	k.Lock()
+	defer k.Unlock()
+	return append([]string{}, k.last...)
+}
+
+func (k *KrakendLogWriter) keep(line string) {
+	k.Lock()
+	defer k.Unlock()
+	k.last = append(k.last, line)
+	if len(k.last) > keptLogLines {
+		k.last = k.last[len(k.last)-keptLogLines:]
+	}
+}
+
+// Write receives the container output line by line
+func (k *KrakendLogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		k.keep(line)
+		log := ParseKrakendLog(line)
+		switch log.Level {
+		case "DEBUG":
+			k.w.Debug(log.Message, log.Fields()...)
+		case "WARNING":
+			k.w.Warn(log.Message, log.Fields()...)
+		case "ERROR", "CRITICAL":
+			k.w.Error(log.Message, log.Fields()...)
+		default:
+			k.w.Info(log.Message, log.Fields()...)
+		}
+	}
+	return len(p), nil
+}
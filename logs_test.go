@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKrakendLog(t *testing.T) {
+	tcs := []struct {
+		name string
+		line string
+		want KrakendLog
+	}{
+		{
+			"endpoint error",
+			"[KRAKEND] 2024/05/01 - 10:00:00.000 ▶ ERROR [ENDPOINT: /module/service/items] Error #01: invalid token",
+			KrakendLog{Level: "ERROR", Module: "ENDPOINT", Route: "/module/service/items", Message: "Error #01: invalid token"},
+		},
+		{
+			"module with detail",
+			"[KRAKEND] 2024/05/01 - 10:00:00.000 ▶ INFO [SERVICE: Gin] Listening on port: 8080",
+			KrakendLog{Level: "INFO", Module: "SERVICE Gin", Message: "Listening on port: 8080"},
+		},
+		{
+			"no module",
+			"[KRAKEND] 2024/05/01 - 10:00:00.000 ▶ WARNING starting",
+			KrakendLog{Level: "WARNING", Message: "starting"},
+		},
+		{
+			"access ok",
+			`[GIN] 2024/05/01 - 10:00:00 | 200 |  1.2ms | 172.17.0.1 | GET      "/module/service/items"`,
+			KrakendLog{Level: "DEBUG", Module: "ACCESS", Route: "/module/service/items", Status: 200, Message: "GET /module/service/items 200 1.2ms"},
+		},
+		{
+			"access unauthorized",
+			`[GIN] 2024/05/01 - 10:00:00 | 401 |  1.2ms | 172.17.0.1 | POST     "/items"`,
+			KrakendLog{Level: "WARNING", Module: "ACCESS", Route: "/items", Status: 401, Message: "POST /items 401 1.2ms"},
+		},
+		{
+			"access failure",
+			`[GIN] 2024/05/01 - 10:00:00 | 502 |  30s | 172.17.0.1 | GET      "/items"`,
+			KrakendLog{Level: "ERROR", Module: "ACCESS", Route: "/items", Status: 502, Message: "GET /items 502 30s"},
+		},
+		{
+			"json",
+			`{"level":"warning","msg":"[ENDPOINT: /items] slow backend"}`,
+			KrakendLog{Level: "WARNING", Module: "ENDPOINT", Route: "/items", Message: "slow backend"},
+		},
+		{
+			"json message",
+			`{"level":"info","message":"ready"}`,
+			KrakendLog{Level: "INFO", Message: "ready"},
+		},
+		{
+			"invalid json",
+			`{not json`,
+			KrakendLog{Level: "INFO", Message: "{not json"},
+		},
+		{
+			"plain",
+			"  Parsing configuration file  ",
+			KrakendLog{Level: "INFO", Message: "Parsing configuration file"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ParseKrakendLog(tc.line); !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("ParseKrakendLog() = %+v; want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKrakendLogWriterKeepsLastLines(t *testing.T) {
+	k := &KrakendLogWriter{}
+	for i := 0; i < keptLogLines+5; i++ {
+		k.keep(string(rune('a' + i)))
+	}
+	last := k.Last()
+	if len(last) != keptLogLines {
+		t.Fatalf("Last() kept %d lines; want %d", len(last), keptLogLines)
+	}
+	if last[0] != "f" {
+		t.Errorf("Last()[0] = %s; want f", last[0])
+	}
+}
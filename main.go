@@ -12,6 +12,8 @@ import (
 	"github.com/codefly-dev/core/wool"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"path"
+	"strings"
 
 	"github.com/codefly-dev/core/agents"
 	"github.com/codefly-dev/core/agents/services"
@@ -27,14 +29,165 @@ var requirements = builders.NewDependencies(agent.Name,
 	builders.NewDependency("routing"),
 )
 
+// Deployment kinds supported by the builder
+const (
+	KustomizeDeployment = "kustomize"
+	HelmDeployment      = "helm"
+	ComposeDeployment   = "docker-compose"
+)
+
+// Build kinds: how the image embeds its configuration
+const (
+	FlexibleBuild = "flexible"
+	StaticBuild   = "static"
+)
+
 type Settings struct {
+	// Deployment selects the generated Kubernetes output: kustomize (default), helm or docker-compose
+	Deployment string `yaml:"deployment,omitempty"`
+
+	// KrakenD image and version used both to run and to build the gateway
+	KrakendImage   string `yaml:"krakend-image,omitempty"`
+	KrakendVersion string `yaml:"krakend-version,omitempty"`
+	// KrakendEdition is ce or ee, by default derived from the image name
+	KrakendEdition string `yaml:"krakend-edition,omitempty"`
+
+	// Build selects the image kind: flexible (default) or static with the rendered configuration baked in
+	Build string `yaml:"build,omitempty"`
+	// BuildEnvironment is the environment whose routing settings are baked in a static build
+	BuildEnvironment string `yaml:"build-environment,omitempty"`
+	// BuildNamespace is the Kubernetes namespace of the dependencies in a static build: their module by default
+	BuildNamespace string `yaml:"build-namespace,omitempty"`
+	// DisableFlexibleConfig makes a static image only run the rendered configuration
+	DisableFlexibleConfig bool `yaml:"disable-flexible-config,omitempty"`
+
+	// ImageEnvironmentVariables are set in the built image: KRAKEND_PORT, FC_PARTIALS...
+	ImageEnvironmentVariables map[string]string `yaml:"image-environment-variables,omitempty"`
+
+	// SyncPolicy exposes new routes when syncing without communication
+	SyncPolicy *SyncPolicy `yaml:"sync-policy,omitempty"`
+
+	// AuthMode chosen at creation: none, jwt, fake or api-key
+	AuthMode string `yaml:"auth-mode,omitempty"`
+
+	// CorsOrigins allowed by the gateway: all if empty
+	CorsOrigins []string `yaml:"cors-origins,omitempty"`
+
+	// GRPCForwarding exposes the RPCs of the gRPC dependencies, with their descriptors in routing/grpc
+	GRPCForwarding bool `yaml:"grpc-forwarding,omitempty"`
+
+	// ServeOpenAPI serves the combined OpenAPI from the gateway at /docs/openapi.json
+	ServeOpenAPI bool `yaml:"serve-openapi,omitempty"`
+	// DocsUI served at /docs/ with the OpenAPI: swagger or redoc
+	DocsUI string `yaml:"docs-ui,omitempty"`
+
+	// BreakingChanges of the public API since the last build: warn (default), fail or ignore
+	BreakingChanges string `yaml:"breaking-changes,omitempty"`
+
+	// Metrics exported for Prometheus
+	Metrics *Metrics `yaml:"metrics,omitempty"`
+	// Tracing exported to an OTLP collector
+	Tracing *Tracing `yaml:"tracing,omitempty"`
+	// Logging of the gateway and its access log
+	Logging *Logging `yaml:"logging,omitempty"`
+
+	// LocalRuntime of the gateway: docker (default) or native with a local krakend binary
+	LocalRuntime string `yaml:"local-runtime,omitempty"`
+	// KrakendBinary run by the native runtime: krakend from the PATH if not set
+	KrakendBinary string `yaml:"krakend-binary,omitempty"`
 }
 
+// Local runtimes of the gateway
+const (
+	DockerRuntime = "docker"
+	NativeRuntime = "native"
+)
+
+// Native is true when the gateway runs as a local process instead of a container
+func (s *Settings) Native() bool {
+	return s.LocalRuntime == NativeRuntime
+}
+
+// krakendBinary run by the native runtime, with default
+func (s *Settings) krakendBinary() string {
+	if s.KrakendBinary == "" {
+		return "krakend"
+	}
+	return s.KrakendBinary
+}
+
+// Authentication modes
+const (
+	NoAuth     = "none"
+	JWTAuth    = "jwt"
+	FakeAuth   = "fake"
+	APIKeyAuth = "api-key"
+)
+
+// runtimeImage is the default KrakenD image
 var runtimeImage = &resources.DockerImage{Name: "devopsfaith/krakend", Tag: "2.6"}
 
+// RuntimeImage is the KrakenD image from settings, with defaults
+func (s *Settings) RuntimeImage() *resources.DockerImage {
+	image := &resources.DockerImage{Name: runtimeImage.Name, Tag: runtimeImage.Tag}
+	if s.KrakendImage != "" {
+		image.Name = s.KrakendImage
+	}
+	if s.KrakendVersion != "" {
+		image.Tag = s.KrakendVersion
+	}
+	return image
+}
+
+// Edition of the KrakenD image: enterprise images are named krakend-ee
+func (s *Settings) Edition() string {
+	if s.KrakendEdition != "" {
+		return s.KrakendEdition
+	}
+	if strings.Contains(s.RuntimeImage().Name, "krakend-ee") {
+		return EnterpriseEdition
+	}
+	return CommunityEdition
+}
+
+// routingMount is where the routing folder is mounted inside the KrakenD container
+const routingMount = "/codefly/routing"
+
+// routingEnvironmentVariables enables the flexible configuration from the routing folder as seen by the gateway
+func routingEnvironmentVariables(root string) []*resources.EnvironmentVariable {
+	return []*resources.EnvironmentVariable{
+		resources.Env("FC_ENABLE", 1),
+		resources.Env("FC_OUT", path.Join(root, "out.json")),
+		resources.Env("FC_SETTINGS", path.Join(root, "config/settings")),
+		resources.Env("FC_CONFIG", path.Join(root, "config/out.json")),
+	}
+}
+
+// krakendArgs run the flexible configuration from the routing folder, in debug mode unless logging says otherwise
+func (s *Settings) krakendArgs(root string) []string {
+	args := []string{"run"}
+	if s.Logging.Debug() {
+		args = append(args, "-d")
+	}
+	return append(args, "-c", path.Join(root, "config/krakend.tmpl"))
+}
+
+// krakendCommand runs the gateway in a container
+func (s *Settings) krakendCommand(root string) []string {
+	return append([]string{"krakend"}, s.krakendArgs(root)...)
+}
+
 type Extension struct {
 	Exposed   bool `yaml:"exposed"`
 	Protected bool `yaml:"protected"`
+
+	// ExtraConfig of the KrakenD endpoint, like qos/ratelimit/router
+	ExtraConfig map[string]any `yaml:"extra-config,omitempty"`
+	// BackendExtraConfig of the KrakenD backend, like qos/http-cache
+	BackendExtraConfig map[string]any `yaml:"backend-extra-config,omitempty"`
+
+	// Signature of the backend route when last synced
+	Signature *Signature `yaml:"signature,omitempty"`
 }
 
 // RestRoute extends the concept of RestRoute to add API Gateway concepts
@@ -49,7 +202,8 @@ type Service struct {
 	// Access
 	port uint16
 
-	restRoutesLocation string
+	restRoutesLocation         string
+	archivedRestRoutesLocation string
 
 	RestRouteGroups []*RestRouteGroup
 
@@ -62,10 +216,29 @@ type Service struct {
 
 	restEndpoint       *basev0.Endpoint
 	openapiDestination string
+
+	// metricsEndpoint when metrics are enabled, and its port when running natively
+	metricsEndpoint   *basev0.Endpoint
+	nativeMetricsPort uint16
+
+	// docsRoot is the docs folder as seen by the gateway
+	docsRoot string
+
+	// environment the configuration is created for
+	environment string
+
+	// native when the gateway runs as a local process
+	native bool
+
+	// compose when the configuration is exported for docker compose
+	compose bool
 }
 
 func (s *Service) Setup(ctx context.Context) error {
 	s.restRoutesLocation = s.Local("routing/rest")
+	s.archivedRestRoutesLocation = s.Local("routing/archived/rest")
+	s.openapiDestination = s.Local("openapi/api.swagger.json")
+	s.docsRoot = imageDocs
 	// Location of openapi
 	dir := s.Local("openapi")
 	_, err := shared.CheckDirectoryOrCreate(ctx, dir)
@@ -113,6 +286,7 @@ func (s *Service) LoadRestRoutes(ctx context.Context) error {
 	s.RestRouteGroups = loader.Groups()
 	s.Wool.Debug("known REST route groups", wool.SliceCountField(s.RestRouteGroups))
 	// Check if we have protected routes
+	s.requiresAuth = s.protectsGRPC()
 	for _, group := range s.RestRouteGroups {
 		for _, route := range group.Routes {
 			if route.Extension.Protected {
@@ -128,6 +302,13 @@ type ValidatorConfiguration struct {
 		Audience string `yaml:"audience"`
 		URL      string `yaml:"url"`
 	} `yaml:"jwt"`
+	Fake *struct {
+		UserAuthID string `yaml:"user-auth-id"`
+	} `yaml:"fake"`
+	APIKey *struct {
+		// Keys are refused here: they belong to the api_keys secret configuration
+		Keys []string `yaml:"keys,omitempty"`
+	} `yaml:"api-key"`
 }
 
 func (s *Service) CreateValidators(ctx context.Context, confs ...*basev0.Configuration) ([]*AuthValidator, error) {
@@ -157,6 +338,40 @@ func (s *Service) CreateValidators(ctx context.Context, confs ...*basev0.Configu
 					Configuration: jwtConf},
 			)
 		}
+		if vc.Fake != nil {
+			modifier := ModifierMartian{
+				HeaderModifier: &HeaderModifier{
+					Scope: []string{"request"},
+					Name:  wool.Header(wool.UserAuthIDKey),
+					Value: vc.Fake.UserAuthID,
+				},
+			}
+			auths = append(auths,
+				&AuthValidator{
+					Key:           ModifierMartianKey,
+					Configuration: modifier},
+			)
+		}
+		if vc.APIKey != nil {
+			if len(vc.APIKey.Keys) > 0 {
+				return nil, s.Wool.NewError("API keys cannot be in the auth configuration: move them to configurations/{env}/%s.secret.env", APIKeysSecret)
+			}
+			if s.Edition() != EnterpriseEdition {
+				return nil, s.Wool.NewError("API keys need KrakenD Enterprise: set krakend-image to krakend/krakend-ee")
+			}
+			keys := &APIKeys{
+				Strategy:   "header",
+				Identifier: "Authorization",
+				Roles:      []string{APIKeyRole},
+				Env:        resources.ServiceSecretConfigurationKey(s.Identity, APIKeysSecret, APIKeysSecretKey),
+			}
+			auths = append(auths,
+				&AuthValidator{
+					Key:           APIKeysKey,
+					Configuration: APIKeysRoles{Roles: []string{APIKeyRole}},
+					Global:        keys},
+			)
+		}
 	}
 	if len(auths) == 0 {
 		return nil, s.Wool.NewError("no auth configuration found")
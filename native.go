@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	runners "github.com/codefly-dev/core/runners/base"
+)
+
+// routingRoot is the routing folder as seen by the gateway: mounted in the container, or local for the native runtime
+func (s *Service) routingRoot() string {
+	if s.native {
+		return s.Local("routing")
+	}
+	return routingMount
+}
+
+// dependenciesNetworkAccess reaches the dependencies from the container, or from the host for the native runtime
+func (s *Service) dependenciesNetworkAccess() *basev0.NetworkAccess {
+	if s.native {
+		return resources.NewNativeNetworkAccess()
+	}
+	return resources.NewContainerNetworkAccess()
+}
+
+// initNative prepares a local krakend process listening on the native network instance
+func (s *Runtime) initNative(ctx context.Context, instance *basev0.NetworkInstance) error {
+	err := s.stopNative(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.port = uint16(instance.Port)
+	s.healthURL = healthURL(s.port)
+	s.Infof("will run natively on: %s", instance.Address)
+	if s.Settings.Metrics != nil {
+		s.nativeMetricsPort = s.metricsHostPort(ctx, resources.NewNativeNetworkAccess())
+		s.Infof("metrics on: http://localhost:%d/metrics", s.nativeMetricsPort)
+	}
+
+	env, err := runners.NewNativeEnvironment(ctx, s.routingRoot())
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create native environment")
+	}
+	bin := s.Settings.krakendBinary()
+	err = env.WithBinary(bin)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot find %s: install KrakenD or use the docker runtime", bin)
+	}
+	env.WithEnvironmentVariables(ctx, routingEnvironmentVariables(s.routingRoot())...)
+	env.WithEnvironmentVariables(ctx, s.secrets...)
+
+	proc, err := env.NewProcess(bin, s.Settings.krakendArgs(s.routingRoot())...)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create %s process", bin)
+	}
+	proc.WithOutput(s.logs)
+	s.proc = proc
+	return nil
+}
+
+// stopNative stops the local krakend process if running
+func (s *Runtime) stopNative(ctx context.Context) error {
+	if s.proc == nil {
+		return nil
+	}
+	running, err := s.proc.IsRunning(ctx)
+	if err != nil || !running {
+		return nil
+	}
+	err = s.proc.Stop(ctx)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot stop krakend process")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Exposure of a route on the gateway
+type Exposure string
+
+const (
+	ExposureProtected Exposure = "protected"
+	ExposurePublic    Exposure = "public"
+	ExposureHidden    Exposure = "hidden"
+)
+
+// Valid exposure
+func (e Exposure) Valid() bool {
+	switch e {
+	case ExposureProtected, ExposurePublic, ExposureHidden:
+		return true
+	default:
+		return false
+	}
+}
+
+// Extension corresponding to the exposure
+func (e Exposure) Extension() Extension {
+	switch e {
+	case ExposureProtected:
+		return Extension{Exposed: true, Protected: true}
+	case ExposurePublic:
+		return Extension{Exposed: true}
+	default:
+		return Extension{}
+	}
+}
+
+// SyncRule sets the exposure of the routes it matches: empty fields match everything
+type SyncRule struct {
+	Module  string `yaml:"module,omitempty"`
+	Service string `yaml:"service,omitempty"`
+	// Path is a glob as in path.Match
+	Path   string `yaml:"path,omitempty"`
+	Method string `yaml:"method,omitempty"`
+
+	Exposure Exposure `yaml:"exposure"`
+}
+
+// Match the rule against a route
+func (r *SyncRule) Match(imp *ImportRoute) bool {
+	if r.Module != "" && r.Module != imp.module {
+		return false
+	}
+	if r.Service != "" && r.Service != imp.service {
+		return false
+	}
+	if r.Method != "" && !strings.EqualFold(r.Method, string(imp.Method)) {
+		return false
+	}
+	if r.Path != "" {
+		if ok, err := path.Match(r.Path, imp.Path); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncPolicy decides the exposure of new routes when syncing without communication
+type SyncPolicy struct {
+	// Default exposure when no rule matches: hidden if not set
+	Default Exposure    `yaml:"default,omitempty"`
+	Rules   []*SyncRule `yaml:"rules,omitempty"`
+
+	// PruneArchived deletes the archived groups whose endpoint is still missing
+	PruneArchived bool `yaml:"prune-archived,omitempty"`
+
+	// EditExposure lets an interactive sync without route changes edit the exposure of the known routes
+	EditExposure bool `yaml:"edit-exposure,omitempty"`
+}
+
+// EditsExposure of the known routes on interactive syncs
+func (p *SyncPolicy) EditsExposure() bool {
+	return p != nil && p.EditExposure
+}
+
+// Prunes archived groups when syncing without communication
+func (p *SyncPolicy) Prunes() bool {
+	return p != nil && p.PruneArchived
+}
+
+// Exposure of a route: first matching rule wins
+func (p *SyncPolicy) Exposure(imp *ImportRoute) Exposure {
+	if p == nil {
+		return ExposureHidden
+	}
+	for _, rule := range p.Rules {
+		if rule.Match(imp) {
+			return rule.Exposure
+		}
+	}
+	if p.Default == "" {
+		return ExposureHidden
+	}
+	return p.Default
+}
+
+// mappingValue is the value of a key in a yaml mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// ValidateSyncPolicy checks the exposures of the sync-policy in a service configuration, reporting their line
+func ValidateSyncPolicy(file string, content []byte) error {
+	var doc yaml.Node
+	err := yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	policy := mappingValue(mappingValue(doc.Content[0], "spec"), "sync-policy")
+	if policy == nil {
+		return nil
+	}
+	check := func(node *yaml.Node) error {
+		if node == nil {
+			return nil
+		}
+		if !Exposure(node.Value).Valid() {
+			return fmt.Errorf("%s: line %d: unknown exposure %q: use %s, %s or %s",
+				file, node.Line, node.Value, ExposureProtected, ExposurePublic, ExposureHidden)
+		}
+		return nil
+	}
+	err = check(mappingValue(policy, "default"))
+	if err != nil {
+		return err
+	}
+	rules := mappingValue(policy, "rules")
+	if rules == nil {
+		return nil
+	}
+	for _, rule := range rules.Content {
+		exposure := mappingValue(rule, "exposure")
+		if exposure == nil {
+			return fmt.Errorf("%s: line %d: sync rule without exposure", file, rule.Line)
+		}
+		err = check(exposure)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
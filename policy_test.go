@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codefly-dev/core/resources"
+)
+
+func importRoute(module string, service string, method resources.HTTPMethod, p string) *ImportRoute {
+	return &ImportRoute{
+		RestRoute: &resources.RestRoute{Path: p, Method: method},
+		module:    module,
+		service:   service,
+	}
+}
+
+func TestSyncRuleMatch(t *testing.T) {
+	route := importRoute("backend", "users", resources.HTTPMethodGet, "/users/{id}")
+	tcs := []struct {
+		name  string
+		rule  SyncRule
+		match bool
+	}{
+		{"empty rule matches everything", SyncRule{}, true},
+		{"module", SyncRule{Module: "backend"}, true},
+		{"other module", SyncRule{Module: "frontend"}, false},
+		{"service", SyncRule{Module: "backend", Service: "users"}, true},
+		{"other service", SyncRule{Service: "orders"}, false},
+		{"method is case insensitive", SyncRule{Method: "get"}, true},
+		{"other method", SyncRule{Method: "POST"}, false},
+		{"path glob", SyncRule{Path: "/users/*"}, true},
+		{"exact path", SyncRule{Path: "/users/{id}"}, true},
+		{"glob does not cross segments", SyncRule{Path: "/*"}, false},
+		{"invalid glob", SyncRule{Path: "/users/["}, false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.Match(route); got != tc.match {
+				t.Errorf("Match() = %v; want %v", got, tc.match)
+			}
+		})
+	}
+}
+
+func TestSyncPolicyExposure(t *testing.T) {
+	route := importRoute("backend", "users", resources.HTTPMethodGet, "/users")
+	var none *SyncPolicy
+	if got := none.Exposure(route); got != ExposureHidden {
+		t.Errorf("nil policy: got %s", got)
+	}
+	policy := &SyncPolicy{
+		Default: ExposureProtected,
+		Rules: []*SyncRule{
+			{Service: "orders", Exposure: ExposureHidden},
+			{Path: "/users", Exposure: ExposurePublic},
+			{Exposure: ExposureHidden},
+		},
+	}
+	if got := policy.Exposure(route); got != ExposurePublic {
+		t.Errorf("first matching rule: got %s", got)
+	}
+	policy.Rules = nil
+	if got := policy.Exposure(route); got != ExposureProtected {
+		t.Errorf("default: got %s", got)
+	}
+}
+
+func TestValidateSyncPolicy(t *testing.T) {
+	tcs := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"no policy", "name: gateway\nspec:\n  deployment: helm\n", ""},
+		{"valid", "spec:\n  sync-policy:\n    default: public\n    rules:\n      - service: users\n        exposure: hidden\n", ""},
+		{"unknown default", "spec:\n  sync-policy:\n    default: exposed\n", "line 3: unknown exposure \"exposed\""},
+		{"unknown rule exposure", "spec:\n  sync-policy:\n    rules:\n      - path: /admin/*\n        exposure: private\n", "line 5: unknown exposure \"private\""},
+		{"missing rule exposure", "spec:\n  sync-policy:\n    rules:\n      - path: /admin/*\n", "line 4: sync rule without exposure"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSyncPolicy("service.codefly.yaml", []byte(tc.content))
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "service.codefly.yaml: "+tc.err) {
+				t.Errorf("got %v; want %s", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/shared"
+	"github.com/go-openapi/spec"
+)
+
+// Postman collection format v2.1
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type PostmanCollection struct {
+	Info     PostmanInfo        `json:"info"`
+	Item     []*PostmanFolder   `json:"item"`
+	Variable []*PostmanVariable `json:"variable,omitempty"`
+}
+
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type PostmanFolder struct {
+	Name string         `json:"name"`
+	Item []*PostmanItem `json:"item"`
+}
+
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Request *PostmanRequest `json:"request"`
+}
+
+type PostmanRequest struct {
+	Method      string        `json:"method"`
+	Header      []*PostmanKey `json:"header"`
+	URL         *PostmanURL   `json:"url"`
+	Body        *PostmanBody  `json:"body,omitempty"`
+	Auth        *PostmanAuth  `json:"auth,omitempty"`
+	Description string        `json:"description,omitempty"`
+}
+
+type PostmanURL struct {
+	Raw  string   `json:"raw"`
+	Host []string `json:"host"`
+	Path []string `json:"path"`
+}
+
+type PostmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+type PostmanAuth struct {
+	Type   string        `json:"type"`
+	Bearer []*PostmanKey `json:"bearer,omitempty"`
+	APIKey []*PostmanKey `json:"apikey,omitempty"`
+}
+
+type PostmanKey struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type PostmanEnvironment struct {
+	Name   string             `json:"name"`
+	Values []*PostmanVariable `json:"values"`
+}
+
+// postmanFolder is where the collection and its environments are written
+func (s *Service) postmanFolder() string {
+	return s.Local("openapi/postman")
+}
+
+var pathParameter = regexp.MustCompile(`{([^}]+)}`)
+
+func splitPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// postmanAuth for protected routes: the token or key comes from the environment, none with fake authentication
+func (s *Service) postmanAuth() *PostmanAuth {
+	switch s.Settings.AuthMode {
+	case APIKeyAuth:
+		return &PostmanAuth{Type: "apikey", APIKey: []*PostmanKey{
+			{Key: "key", Value: "Authorization", Type: "string"},
+			{Key: "value", Value: "{{apiKey}}", Type: "string"},
+			{Key: "in", Value: "header", Type: "string"},
+		}}
+	case JWTAuth:
+		return &PostmanAuth{Type: "bearer", Bearer: []*PostmanKey{{Key: "token", Value: "{{token}}", Type: "string"}}}
+	default:
+		return nil
+	}
+}
+
+func postmanItem(name string, method string, route string, op *spec.Operation) *PostmanItem {
+	raw := pathParameter.ReplaceAllString(route, ":$1")
+	request := &PostmanRequest{
+		Method: method,
+		Header: []*PostmanKey{},
+		URL: &PostmanURL{
+			Raw:  "{{baseUrl}}" + raw,
+			Host: []string{"{{baseUrl}}"},
+			Path: splitPath(raw),
+		},
+	}
+	if op != nil {
+		if op.Summary != "" {
+			name = op.Summary
+		}
+		request.Description = op.Description
+		for _, param := range op.Parameters {
+			if param.In == "body" {
+				request.Header = append(request.Header, &PostmanKey{Key: "Content-Type", Value: "application/json"})
+				request.Body = &PostmanBody{Mode: "raw", Raw: "{}"}
+			}
+		}
+	}
+	return &PostmanItem{Name: name, Request: request}
+}
+
+// PostmanCollection has one request per exposed route, grouped by module/service
+func (s *Service) PostmanCollection(ctx context.Context) (*PostmanCollection, error) {
+	exists, err := shared.FileExists(ctx, s.openapiDestination)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "cannot check combined openapi")
+	}
+	var swagger *spec.Swagger
+	if exists {
+		loaded, err := loadSwagger(s.openapiDestination)
+		if err != nil {
+			return nil, s.Wool.Wrapf(err, "cannot load combined openapi")
+		}
+		swagger = loaded
+	}
+
+	collection := &PostmanCollection{
+		Info:     PostmanInfo{Name: s.Base.Service.Name, Schema: postmanSchema},
+		Variable: []*PostmanVariable{{Key: "baseUrl", Value: "http://localhost:8080", Type: "string"}},
+	}
+	folders := make(map[string]*PostmanFolder)
+	for _, group := range s.RestRouteGroups {
+		baseGroup := resources.UnwrapRestRouteGroup(group)
+		for _, route := range group.Routes {
+			if !route.Extension.Exposed {
+				continue
+			}
+			target := gatewayRestTarget(baseGroup)
+			var op *spec.Operation
+			if swagger != nil {
+				if item, ok := swagger.Paths.Paths[target]; ok {
+					op = operationFor(item, string(route.Method))
+				}
+			}
+			item := postmanItem(fmt.Sprintf("%s %s", route.Method, route.Path), string(route.Method), target, op)
+			if route.Extension.Protected {
+				item.Request.Auth = s.postmanAuth()
+			}
+			folder, ok := folders[baseGroup.ServiceUnique()]
+			if !ok {
+				folder = &PostmanFolder{Name: baseGroup.ServiceUnique()}
+				folders[baseGroup.ServiceUnique()] = folder
+				collection.Item = append(collection.Item, folder)
+			}
+			folder.Item = append(folder.Item, item)
+		}
+	}
+	sort.Slice(collection.Item, func(i, j int) bool { return collection.Item[i].Name < collection.Item[j].Name })
+	return collection, nil
+}
+
+func (s *Service) writePostmanFile(ctx context.Context, name string, v any) error {
+	_, err := shared.CheckDirectoryOrCreate(ctx, s.postmanFolder())
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create postman folder")
+	}
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot marshal %s", name)
+	}
+	err = os.WriteFile(path.Join(s.postmanFolder(), name), content, 0o644)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write %s", name)
+	}
+	return nil
+}
+
+// WritePostmanCollection of the exposed routes
+func (s *Service) WritePostmanCollection(ctx context.Context) error {
+	collection, err := s.PostmanCollection(ctx)
+	if err != nil {
+		return err
+	}
+	return s.writePostmanFile(ctx, "collection.json", collection)
+}
+
+// WritePostmanEnvironment with the gateway base URL in a codefly environment
+func (s *Service) WritePostmanEnvironment(ctx context.Context, env string, baseURL string) error {
+	environment := &PostmanEnvironment{
+		Name: fmt.Sprintf("%s (%s)", s.Base.Service.Name, env),
+		Values: []*PostmanVariable{
+			{Key: "baseUrl", Value: baseURL, Type: "default"},
+		},
+	}
+	switch s.Settings.AuthMode {
+	case APIKeyAuth:
+		environment.Values = append(environment.Values, &PostmanVariable{Key: "apiKey", Type: "secret"})
+	case JWTAuth:
+		environment.Values = append(environment.Values, &PostmanVariable{Key: "token", Type: "secret"})
+	}
+	return s.writePostmanFile(ctx, fmt.Sprintf("%s.postman_environment.json", env), environment)
+}
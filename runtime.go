@@ -9,6 +9,7 @@ import (
 	"github.com/codefly-dev/core/resources"
 	runners "github.com/codefly-dev/core/runners/base"
 	"github.com/codefly-dev/core/wool"
+	"path"
 )
 
 type Runtime struct {
@@ -16,6 +17,19 @@ type Runtime struct {
 
 	// internal
 	runner *runners.DockerEnvironment
+	logs   *KrakendLogWriter
+
+	// native runtime
+	proc runners.Proc
+
+	// healthURL of the gateway from the host
+	healthURL string
+
+	// secrets of the service configuration, like the API keys
+	secrets []*resources.EnvironmentVariable
+
+	// live routes of the running gateway
+	live *LiveRouteTable
 }
 
 func NewRuntime() *Runtime {
@@ -39,6 +53,7 @@ func (s *Runtime) Load(ctx context.Context, req *runtimev0.LoadRequest) (*runtim
 	}
 
 	s.Runtime.SetEnvironment(req.Environment)
+	s.environment = req.Environment.Name
 
 	err = s.Setup(ctx)
 	if err != nil {
@@ -65,7 +80,16 @@ func (s *Runtime) Load(ctx context.Context, req *runtimev0.LoadRequest) (*runtim
 		return s.Runtime.LoadError(s.Wool.NewError("cannot find REST endpoint"))
 	}
 
-	s.openapiDestination = s.Local("openapi/api.swagger.json")
+	if s.Settings.Metrics != nil {
+		err = s.createMetricsEndpoint(ctx)
+		if err != nil {
+			return s.Runtime.LoadError(err)
+		}
+		s.Endpoints = append(s.Endpoints, s.metricsEndpoint)
+	}
+
+	s.native = s.Settings.Native()
+	s.docsRoot = path.Join(s.routingRoot(), "docs")
 
 	//if s.Settings.Watch && s.Watcher == nil {
 	//	s.Wool.Debug("setting up code watcher")
@@ -107,10 +131,46 @@ func (s *Runtime) Init(ctx context.Context, req *runtimev0.InitRequest) (*runtim
 		return s.Runtime.InitError(err)
 	}
 
+	err = s.WriteDocs(ctx)
+	if err != nil {
+		return s.Runtime.InitError(err)
+	}
+
+	err = s.WritePostmanCollection(ctx)
+	if err != nil {
+		return s.Runtime.InitError(err)
+	}
+
 	s.Wool.Debug("looking for network instance", wool.Field("endpoint", resources.MakeEndpointSummary(s.restEndpoint)))
 
 	s.NetworkMappings = req.ProposedNetworkMappings
 
+	native, err := resources.FindNetworkInstanceInNetworkMappings(ctx, s.NetworkMappings, s.restEndpoint, resources.NewNativeNetworkAccess())
+	if err == nil && native != nil {
+		err = s.WritePostmanEnvironment(ctx, s.Runtime.Environment.Name, native.Address)
+		if err != nil {
+			return s.Runtime.InitError(err)
+		}
+	}
+
+	s.logs = NewKrakendLogWriter(s.Wool)
+
+	s.secrets = nil
+	if req.Configuration != nil {
+		s.secrets = resources.ConfigurationAsEnvironmentVariables(req.Configuration, true)
+	}
+
+	if s.native {
+		if native == nil {
+			return s.Runtime.InitError(s.Wool.NewError("cannot find native network instance: %v", resources.MakeManyNetworkMappingSummary(s.NetworkMappings)))
+		}
+		err = s.initNative(ctx, native)
+		if err != nil {
+			return s.Runtime.InitError(err)
+		}
+		return s.Runtime.InitResponse()
+	}
+
 	net, err := resources.FindNetworkInstanceInNetworkMappings(ctx, s.NetworkMappings, s.restEndpoint, resources.NewContainerNetworkAccess())
 	if err != nil {
 		return s.Runtime.InitErrorf(err, "cannot find network instance: %v", resources.MakeManyNetworkMappingSummary(s.NetworkMappings))
@@ -128,26 +188,27 @@ func (s *Runtime) Init(ctx context.Context, req *runtimev0.InitRequest) (*runtim
 		}
 	}
 
-	runner, err := runners.NewDockerHeadlessEnvironment(ctx, runtimeImage, s.UniqueWithWorkspace())
+	runner, err := runners.NewDockerHeadlessEnvironment(ctx, s.RuntimeImage(), s.UniqueWithWorkspace())
 	if err != nil {
 		return s.Runtime.InitError(err)
 	}
 
 	s.runner = runner
+	s.runner.WithOutput(s.logs)
 
-	s.runner.WithMount(s.Local("routing"), "/codefly/routing")
+	s.runner.WithMount(s.Local("routing"), routingMount)
 	s.runner.WithPortMapping(ctx, uint16(net.Port), s.port)
-
-	envs := []*resources.EnvironmentVariable{
-		resources.Env("FC_ENABLE", 1),
-		resources.Env("FC_OUT", "/codefly/routing/out.json"),
-		resources.Env("FC_SETTINGS", "/codefly/routing/config/settings"),
-		resources.Env("FC_CONFIG", "/codefly/routing/config/out.json"),
+	s.healthURL = healthURL(uint16(net.Port))
+	if metrics := s.Settings.Metrics; metrics != nil {
+		port := s.metricsHostPort(ctx, resources.NewContainerNetworkAccess())
+		s.runner.WithPortMapping(ctx, port, metrics.ContainerPort())
+		s.Infof("metrics on: http://localhost:%d/metrics", port)
 	}
 
-	s.runner.WithEnvironmentVariables(ctx, envs...)
+	s.runner.WithEnvironmentVariables(ctx, routingEnvironmentVariables(routingMount)...)
+	s.runner.WithEnvironmentVariables(ctx, s.secrets...)
 
-	s.runner.WithCommand("krakend", "run", "-d", "-c", "/codefly/routing/config/krakend.tmpl")
+	s.runner.WithCommand(s.krakendCommand(routingMount)...)
 
 	return s.Runtime.InitResponse()
 }
@@ -158,7 +219,7 @@ func (s *Runtime) Start(ctx context.Context, req *runtimev0.StartRequest) (*runt
 
 	s.Runtime.LogStartRequest(req)
 
-	err := s.writeConfig(ctx, req.DependenciesNetworkMappings, resources.NewContainerNetworkAccess())
+	err := s.writeConfig(ctx, req.DependenciesNetworkMappings, s.dependenciesNetworkAccess())
 	if err != nil {
 		return s.Runtime.StartError(err)
 	}
@@ -166,23 +227,48 @@ func (s *Runtime) Start(ctx context.Context, req *runtimev0.StartRequest) (*runt
 	runContext := context.Background()
 	runContext = s.Wool.Inject(runContext)
 
-	err = s.runner.Init(runContext)
+	if s.native {
+		err = s.proc.Start(runContext)
+	} else {
+		err = s.runner.Init(runContext)
+	}
+
+	if err != nil {
+		return s.Runtime.StartError(err)
+	}
 
+	s.Wool.Debug("waiting for gateway", wool.Field("health", s.healthURL))
+	err = s.WaitForReady(ctx)
 	if err != nil {
 		return s.Runtime.StartError(err)
 	}
 
+	s.live, err = s.LoadLiveRoutes()
+	if err != nil {
+		s.Wool.Warn("cannot load live routes", wool.ErrField(err))
+	}
+
 	return s.Runtime.StartResponse()
 }
 
 func (s *Runtime) Information(ctx context.Context, req *runtimev0.InformationRequest) (*runtimev0.InformationResponse, error) {
-	return s.Runtime.InformationResponse(ctx, req)
+	resp, err := s.Runtime.InformationResponse(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	return s.withLiveRoutes(resp), nil
 }
 
 func (s *Runtime) Stop(ctx context.Context, req *runtimev0.StopRequest) (*runtimev0.StopResponse, error) {
 	defer s.Wool.Catch()
 
 	s.Wool.Debug("stopping service")
+	s.live = nil
+	err := s.stopNative(ctx)
+	if err != nil {
+		return s.Runtime.StopError(err)
+	}
+
 	if s.runner != nil {
 		err := s.runner.Stop(ctx)
 		if err != nil {
@@ -195,7 +281,7 @@ func (s *Runtime) Stop(ctx context.Context, req *runtimev0.StopRequest) (*runtim
 		}
 	}
 
-	err := s.Base.Stop()
+	err = s.Base.Stop()
 	if err != nil {
 		return s.Runtime.StopError(err)
 	}
@@ -207,7 +293,15 @@ func (s *Runtime) Test(ctx context.Context, req *runtimev0.TestRequest) (*runtim
 }
 
 func (s *Runtime) Destroy(ctx context.Context, req *runtimev0.DestroyRequest) (*runtimev0.DestroyResponse, error) {
-	return s.Runtime.DestroyResponse()
+	defer s.Wool.Catch()
+	ctx = s.Wool.Inject(ctx)
+
+	s.Wool.Debug("destroying service")
+	removed, err := s.destroy(ctx)
+	if err != nil {
+		return s.Runtime.DestroyError(err)
+	}
+	return s.destroyResponse(removed)
 }
 
 func (s *Runtime) Communicate(ctx context.Context, req *agentv0.Engage) (*agentv0.InformationRequest, error) {
@@ -223,3 +317,13 @@ func (s *Runtime) EventHandler(event code.Change) error {
 	s.Runtime.DesiredLoad()
 	return nil
 }
+
+// metricsHostPort allocated by the network manager to the metrics, the container port if none
+func (s *Runtime) metricsHostPort(ctx context.Context, access *v0.NetworkAccess) uint16 {
+	instance, err := resources.FindNetworkInstanceInNetworkMappings(ctx, s.NetworkMappings, s.metricsEndpoint, access)
+	if err != nil {
+		s.Wool.Warn("no network instance for metrics, using the container port", wool.ErrField(err))
+		return s.Settings.Metrics.ContainerPort()
+	}
+	return uint16(instance.Port)
+}
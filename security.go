@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/codefly-dev/core/resources"
+	"github.com/go-openapi/spec"
+)
+
+// securityScheme of the protected routes in the combined OpenAPI: none when clients send no credential
+func (s *Service) securityScheme() (string, map[string]any) {
+	switch s.Settings.AuthMode {
+	case APIKeyAuth:
+		return "apiKey", map[string]any{"type": "apiKey", "name": "Authorization", "in": "header", "description": "API key"}
+	case JWTAuth:
+		// the HTTP bearer scheme: OpenAPI 2.0 has no type for it, and go-openapi drops its fields
+		return "bearer", map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT", "description": "JWT"}
+	default:
+		return "", nil
+	}
+}
+
+// protectedOperations are the method by combined path of the protected routes
+func (s *Service) protectedOperations() map[string][]string {
+	protected := make(map[string][]string)
+	for _, group := range s.RestRouteGroups {
+		baseGroup := resources.UnwrapRestRouteGroup(group)
+		for _, route := range group.Routes {
+			if !route.Extension.Exposed || !route.Extension.Protected {
+				continue
+			}
+			path := fmt.Sprintf("/%s%s", baseGroup.ServiceUnique(), baseGroup.Path)
+			protected[path] = append(protected[path], string(route.Method))
+		}
+	}
+	return protected
+}
+
+// SecureOpenAPI declares the security scheme in the combined OpenAPI and requires it on the protected routes
+func (s *Service) SecureOpenAPI(ctx context.Context) error {
+	protected := s.protectedOperations()
+	name, scheme := s.securityScheme()
+	if len(protected) == 0 || scheme == nil {
+		return nil
+	}
+	content, err := os.ReadFile(s.openapiDestination)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot read combined openapi")
+	}
+	var swagger spec.Swagger
+	err = json.Unmarshal(content, &swagger)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot parse combined openapi")
+	}
+	if swagger.Paths == nil {
+		return nil
+	}
+
+	requirement := []map[string][]string{{name: {}}}
+
+	for path, methods := range protected {
+		item, ok := swagger.Paths.Paths[path]
+		if !ok {
+			continue
+		}
+		for _, method := range methods {
+			if op := operationFor(item, method); op != nil {
+				op.Security = requirement
+			}
+		}
+		swagger.Paths.Paths[path] = item
+	}
+
+	out, err := json.Marshal(&swagger)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot marshal combined openapi")
+	}
+	var document map[string]any
+	err = json.Unmarshal(out, &document)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot parse combined openapi")
+	}
+	document["securityDefinitions"] = map[string]any{name: scheme}
+	out, err = json.Marshal(document)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot marshal combined openapi")
+	}
+	err = os.WriteFile(s.openapiDestination, out, 0o600)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot write combined openapi")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSecurityScheme(t *testing.T) {
+	tcs := []struct {
+		authMode string
+		name     string
+		scheme   string
+	}{
+		{JWTAuth, "bearer", `{"bearerFormat":"JWT","description":"JWT","scheme":"bearer","type":"http"}`},
+		{APIKeyAuth, "apiKey", `{"description":"API key","in":"header","name":"Authorization","type":"apiKey"}`},
+		{FakeAuth, "", ""},
+		{NoAuth, "", ""},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.authMode, func(t *testing.T) {
+			s := NewService()
+			s.AuthMode = tc.authMode
+			name, scheme := s.securityScheme()
+			if name != tc.name {
+				t.Errorf("name = %q; want %q", name, tc.name)
+			}
+			if scheme == nil {
+				if tc.scheme != "" {
+					t.Errorf("no scheme; want %s", tc.scheme)
+				}
+				if s.postmanAuth() != nil {
+					t.Errorf("no credential to send with %s", tc.authMode)
+				}
+				return
+			}
+			content, err := json.Marshal(scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.scheme {
+				t.Errorf("scheme = %s; want %s", content, tc.scheme)
+			}
+			if s.postmanAuth() == nil {
+				t.Errorf("protected routes should carry the %s credential in Postman", tc.authMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/go-openapi/spec"
+)
+
+// Signature of a backend route: hashes of its parameters, request body and responses
+type Signature struct {
+	Parameters string `yaml:"parameters,omitempty"`
+	Body       string `yaml:"body,omitempty"`
+	Responses  string `yaml:"responses,omitempty"`
+}
+
+// Changes between two signatures, as a summary
+func (sig *Signature) Changes(other *Signature) []string {
+	var changes []string
+	if sig.Parameters != other.Parameters {
+		changes = append(changes, "parameters")
+	}
+	if sig.Body != other.Body {
+		changes = append(changes, "request body")
+	}
+	if sig.Responses != other.Responses {
+		changes = append(changes, "responses")
+	}
+	return changes
+}
+
+func operationFor(item spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "POST":
+		return item.Post
+	case "PUT":
+		return item.Put
+	case "PATCH":
+		return item.Patch
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	case "HEAD":
+		return item.Head
+	}
+	return nil
+}
+
+var definitionRef = regexp.MustCompile(`"\$ref":"#/definitions/([^"]+)"`)
+
+// hash content with the definitions it references, so that a change in a referenced schema changes the hash
+func hash(definitions spec.Definitions, v any) string {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	visited := make(map[string]bool)
+	queue := []string{string(content)}
+	var parts []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		parts = append(parts, current)
+		for _, match := range definitionRef.FindAllStringSubmatch(current, -1) {
+			name := match[1]
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			definition, ok := definitions[name]
+			if !ok {
+				continue
+			}
+			out, err := json.Marshal(definition)
+			if err != nil {
+				continue
+			}
+			queue = append(queue, string(out))
+		}
+	}
+	sort.Strings(parts[1:])
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// NewSignature of an operation
+func NewSignature(swagger *spec.Swagger, item spec.PathItem, op *spec.Operation) *Signature {
+	var parameters []spec.Parameter
+	var body []spec.Parameter
+	for _, param := range append(item.Parameters, op.Parameters...) {
+		if param.In == "body" {
+			body = append(body, param)
+			continue
+		}
+		parameters = append(parameters, param)
+	}
+	return &Signature{
+		Parameters: hash(swagger.Definitions, parameters),
+		Body:       hash(swagger.Definitions, body),
+		Responses:  hash(swagger.Definitions, op.Responses),
+	}
+}
+
+// SignaturesFromEndpoints computes the signatures of all routes in the OpenAPI of REST endpoints, by route unique
+func SignaturesFromEndpoints(ctx context.Context, endpoints []*basev0.Endpoint) (map[string]*Signature, error) {
+	signatures := make(map[string]*Signature)
+	for _, endpoint := range endpoints {
+		rest := resources.IsRest(ctx, endpoint)
+		if rest == nil || rest.Openapi == nil {
+			continue
+		}
+		swagger, err := resources.ParseOpenAPI(rest.Openapi)
+		if err != nil {
+			return nil, err
+		}
+		if swagger.Paths == nil {
+			continue
+		}
+		for path, item := range swagger.Paths.Paths {
+			for _, method := range httpMethods {
+				op := operationFor(item, method)
+				if op == nil {
+					continue
+				}
+				imp := &ImportRoute{
+					RestRoute: &resources.RestRoute{Path: path, Method: resources.HTTPMethod(method)},
+					module:    endpoint.Module,
+					service:   endpoint.Service,
+				}
+				signatures[imp.Unique()] = NewSignature(swagger, item, op)
+			}
+		}
+	}
+	return signatures, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestSignatureChanges(t *testing.T) {
+	base := &Signature{Parameters: "p", Body: "b", Responses: "r"}
+	tcs := []struct {
+		name    string
+		other   *Signature
+		changes []string
+	}{
+		{"same", &Signature{Parameters: "p", Body: "b", Responses: "r"}, nil},
+		{"parameters", &Signature{Parameters: "x", Body: "b", Responses: "r"}, []string{"parameters"}},
+		{"body", &Signature{Parameters: "p", Body: "x", Responses: "r"}, []string{"request body"}},
+		{"responses", &Signature{Parameters: "p", Body: "b", Responses: "x"}, []string{"responses"}},
+		{"everything", &Signature{}, []string{"parameters", "request body", "responses"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := base.Changes(tc.other); !reflect.DeepEqual(got, tc.changes) {
+				t.Errorf("Changes() = %v; want %v", got, tc.changes)
+			}
+		})
+	}
+}
+
+const signatureSwagger = `{
+  "swagger": "2.0",
+  "paths": {
+    "/users": {
+      "post": {
+        "parameters": [
+          {"name": "dry", "in": "query", "type": "boolean"},
+          {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}
+        ],
+        "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/User"}}}
+      }
+    }
+  },
+  "definitions": {
+    "User": {"type": "object", "properties": {"name": {"type": "string"}, "address": {"$ref": "#/definitions/Address"}}},
+    "Address": {"type": "object", "properties": {"city": {"type": "string"}}}
+  }
+}`
+
+func signatureOf(t *testing.T, edit func(*spec.Swagger)) *Signature {
+	t.Helper()
+	var swagger spec.Swagger
+	err := json.Unmarshal([]byte(signatureSwagger), &swagger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != nil {
+		edit(&swagger)
+	}
+	item := swagger.Paths.Paths["/users"]
+	return NewSignature(&swagger, item, operationFor(item, "POST"))
+}
+
+func TestNewSignature(t *testing.T) {
+	base := signatureOf(t, nil)
+	if base.Parameters == "" || base.Body == "" || base.Responses == "" {
+		t.Fatalf("incomplete signature: %+v", base)
+	}
+	tcs := []struct {
+		name    string
+		edit    func(*spec.Swagger)
+		changes []string
+	}{
+		{"unchanged", func(*spec.Swagger) {}, nil},
+		{"query parameter", func(s *spec.Swagger) {
+			s.Paths.Paths["/users"].Post.Parameters[0].Type = "string"
+		}, []string{"parameters"}},
+		{"nested definition", func(s *spec.Swagger) {
+			address := s.Definitions["Address"]
+			address.Properties["zip"] = *spec.StringProperty()
+		}, []string{"request body", "responses"}},
+		{"unreferenced definition", func(s *spec.Swagger) {
+			s.Definitions["Other"] = *spec.StringProperty()
+		}, nil},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := base.Changes(signatureOf(t, tc.edit)); !reflect.DeepEqual(got, tc.changes) {
+				t.Errorf("Changes() = %v; want %v", got, tc.changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	basev0 "github.com/codefly-dev/core/generated/go/codefly/base/v0"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/shared"
+	"github.com/codefly-dev/core/wool"
+)
+
+// OpenTelemetryKey for metrics and traces export
+const OpenTelemetryKey = "telemetry/opentelemetry"
+
+// defaultMetricsPort is where KrakenD serves the Prometheus metrics
+const defaultMetricsPort = 9090
+
+// MetricsEndpoint is the name of the endpoint of the metrics, allocated by the network manager when running
+const MetricsEndpoint = "metrics"
+
+// Metrics exported for Prometheus scraping
+type Metrics struct {
+	// Port of the metrics in the container: 9090 if not set
+	Port uint16 `yaml:"port,omitempty"`
+}
+
+// ContainerPort of the metrics, with default
+func (m *Metrics) ContainerPort() uint16 {
+	if m.Port == 0 {
+		return defaultMetricsPort
+	}
+	return m.Port
+}
+
+// createMetricsEndpoint so that the network manager allocates a port to the metrics
+func (s *Service) createMetricsEndpoint(ctx context.Context) error {
+	endpoint := s.Base.BaseEndpoint(MetricsEndpoint)
+	var err error
+	s.metricsEndpoint, err = resources.NewAPI(ctx, endpoint, resources.ToHTTPAPI(&basev0.HttpAPI{}))
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create metrics endpoint")
+	}
+	return nil
+}
+
+// defaultSampleRate exports all traces
+const defaultSampleRate = 1.0
+
+// Tracing exported with OTLP
+type Tracing struct {
+	// Endpoints of the OTLP collector (host:port) by environment: a local collector if not set
+	Endpoints map[string]string `yaml:"endpoints,omitempty"`
+	// SampleRate of the traces between 0 and 1: all traces if not set
+	SampleRate *float64 `yaml:"sample-rate,omitempty"`
+}
+
+// Default OTLP collectors: from the gateway container when running locally in Docker,
+// the collector service of the docker compose export, next to the gateway otherwise
+const (
+	localCollector          = "host.docker.internal:4317"
+	composeCollectorService = "otel-collector"
+	composeCollector        = composeCollectorService + ":4317"
+	deployCollector         = "localhost:4317"
+)
+
+// traceContextHeaders are the W3C trace context headers forwarded to the backends
+var traceContextHeaders = []string{"traceparent", "tracestate"}
+
+// Endpoint of the collector in an environment
+func (t *Tracing) Endpoint(env string, native bool, compose bool) string {
+	if endpoint, ok := t.Endpoints[env]; ok {
+		return endpoint
+	}
+	if compose {
+		return composeCollector
+	}
+	if env == resources.LocalEnvironment().Name && !native {
+		return localCollector
+	}
+	return deployCollector
+}
+
+type OpenTelemetry struct {
+	ServiceName     string                 `json:"service_name"`
+	TraceSampleRate *float64               `json:"trace_sample_rate,omitempty"`
+	Exporters       OpenTelemetryExporters `json:"exporters"`
+}
+
+type OpenTelemetryExporters struct {
+	Prometheus []PrometheusExporter `json:"prometheus,omitempty"`
+	OTLP       []OTLPExporter       `json:"otlp,omitempty"`
+}
+
+type OTLPExporter struct {
+	Name           string `json:"name"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	UseHTTP        bool   `json:"use_http"`
+	DisableMetrics bool   `json:"disable_metrics"`
+}
+
+type PrometheusExporter struct {
+	Name           string `json:"name"`
+	Port           uint16 `json:"port"`
+	ProcessMetrics bool   `json:"process_metrics"`
+	GoMetrics      bool   `json:"go_metrics"`
+}
+
+// metricsPort is the container port of the metrics, 0 when disabled
+func (s *Service) metricsPort() uint16 {
+	if s.Settings.Metrics == nil {
+		return 0
+	}
+	return s.Settings.Metrics.ContainerPort()
+}
+
+// otlpExporter to the collector of the environment
+func (s *Service) otlpExporter() (*OTLPExporter, error) {
+	endpoint := s.Settings.Tracing.Endpoint(s.environment, s.native, s.compose)
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "invalid OTLP collector endpoint: %s", endpoint)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, s.Wool.Wrapf(err, "invalid OTLP collector port: %s", endpoint)
+	}
+	return &OTLPExporter{Name: "collector", Host: host, Port: p, DisableMetrics: true}, nil
+}
+
+// telemetry adds the OpenTelemetry exporters from settings
+func (s *Service) telemetry(settings *KrakendSettings) error {
+	telemetry := OpenTelemetry{ServiceName: s.Base.Service.Name}
+	exporters := &telemetry.Exporters
+	if metrics := s.Settings.Metrics; metrics != nil {
+		port := metrics.ContainerPort()
+		if s.native && s.nativeMetricsPort != 0 {
+			port = s.nativeMetricsPort
+		}
+		exporters.Prometheus = append(exporters.Prometheus, PrometheusExporter{
+			Name:           "prometheus",
+			Port:           port,
+			ProcessMetrics: true,
+			GoMetrics:      true,
+		})
+	}
+	if s.Settings.Tracing != nil {
+		exporter, err := s.otlpExporter()
+		if err != nil {
+			return err
+		}
+		exporters.OTLP = append(exporters.OTLP, *exporter)
+		telemetry.TraceSampleRate = s.Settings.Tracing.SampleRate
+		if telemetry.TraceSampleRate == nil {
+			// KrakenD samples no trace by default
+			telemetry.TraceSampleRate = shared.Pointer(defaultSampleRate)
+		}
+	}
+	if len(exporters.Prometheus) == 0 && len(exporters.OTLP) == 0 {
+		return nil
+	}
+	settings.ExtraConfig[OpenTelemetryKey] = telemetry
+	return nil
+}
+
+// forwardedHeaders to the backends: the codefly context, and the trace context when tracing
+func (s *Service) forwardedHeaders() []string {
+	headers := wool.Headers()
+	if s.Settings.Tracing != nil {
+		headers = append(headers, traceContextHeaders...)
+	}
+	return headers
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestTracingEndpoint(t *testing.T) {
+	tracing := &Tracing{Endpoints: map[string]string{"production": "otel.observability:4317"}}
+	tcs := []struct {
+		name    string
+		env     string
+		native  bool
+		compose bool
+		want    string
+	}{
+		{"configured", "production", false, true, "otel.observability:4317"},
+		{"local docker", "local", false, false, localCollector},
+		{"local native", "local", true, false, deployCollector},
+		{"compose", "staging", false, true, composeCollector},
+		{"deployed", "staging", false, false, deployCollector},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tracing.Endpoint(tc.env, tc.native, tc.compose); got != tc.want {
+				t.Errorf("Endpoint() = %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
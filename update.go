@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/codefly-dev/core/agents/services"
+	"github.com/codefly-dev/core/resources"
+	"github.com/codefly-dev/core/wool"
+)
+
+// snapshot of the generated files, to report what an update changed
+type snapshot map[string][]byte
+
+func (s *Service) takeSnapshot() (snapshot, error) {
+	snap := make(snapshot)
+	files := []string{s.Local("GETTING_STARTED.md")}
+	err := filepath.WalkDir(s.Local("routing"), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, s.Wool.Wrapf(err, "cannot walk routing folder")
+	}
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, s.Wool.Wrapf(err, "cannot read %s", f)
+		}
+		snap[f] = content
+	}
+	return snap, nil
+}
+
+// diff returns the changed files, relative to the service
+func (s *Service) diff(before snapshot, after snapshot) []string {
+	var changed []string
+	for f, content := range after {
+		if previous, ok := before[f]; ok && bytes.Equal(previous, content) {
+			continue
+		}
+		changed = append(changed, f)
+	}
+	for f := range before {
+		if _, ok := after[f]; !ok {
+			changed = append(changed, f)
+		}
+	}
+	for i, f := range changed {
+		if rel, err := filepath.Rel(s.Location, f); err == nil {
+			changed[i] = rel
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// templatesManifest records the hash of the factory files as generated, to detect the ones modified since
+const templatesManifest = "builder/templates.sum.json"
+
+func contentHash(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// loadTemplateHashes returns false when the manifest is missing, for services generated before it existed
+func (s *Service) loadTemplateHashes() (map[string]string, bool, error) {
+	hashes := make(map[string]string)
+	content, err := os.ReadFile(s.Local(templatesManifest))
+	if os.IsNotExist(err) {
+		return hashes, false, nil
+	}
+	if err != nil {
+		return nil, false, s.Wool.Wrapf(err, "cannot read template hashes")
+	}
+	err = json.Unmarshal(content, &hashes)
+	if err != nil {
+		return nil, false, s.Wool.Wrapf(err, "cannot parse template hashes")
+	}
+	return hashes, true, nil
+}
+
+func (s *Service) saveTemplateHashes(hashes map[string]string) error {
+	content, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot marshal template hashes")
+	}
+	err = os.MkdirAll(filepath.Dir(s.Local(templatesManifest)), 0o755)
+	if err != nil {
+		return s.Wool.Wrapf(err, "cannot create builder folder")
+	}
+	return os.WriteFile(s.Local(templatesManifest), content, 0o644)
+}
+
+// WriteFactoryFiles renders the factory templates, skipping the files modified since they were generated.
+// Without manifest, the differing files are kept once and recorded as generated: the next update refreshes them.
+// It returns the modified files it kept and the ones it could not tell apart.
+func (s *Builder) WriteFactoryFiles(ctx context.Context) ([]string, []string, error) {
+	tmp, err := os.MkdirTemp("", "krakend-factory")
+	if err != nil {
+		return nil, nil, s.Wool.Wrapf(err, "cannot create temporary folder")
+	}
+	defer os.RemoveAll(tmp)
+
+	err = s.Templates(ctx, s.Information, services.WithFactory(factoryFS).WithDestination(tmp))
+	if err != nil {
+		return nil, nil, s.Wool.Wrapf(err, "cannot render templates")
+	}
+
+	hashes, recorded, err := s.loadTemplateHashes()
+	if err != nil {
+		return nil, nil, err
+	}
+	var skipped, unrecorded []string
+	err = filepath.WalkDir(tmp, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(tmp, p)
+		if err != nil {
+			return err
+		}
+		if rel == filepath.Join("routing", "README.md") {
+			// re-written by the route inventory
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		target := s.Local(rel)
+		current, err := os.ReadFile(target)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return err
+		case bytes.Equal(current, content):
+			hashes[rel] = contentHash(content)
+			return nil
+		case !recorded:
+			s.Wool.Warn("keeping file without record of its generated version", wool.FileField(rel))
+			hashes[rel] = contentHash(current)
+			unrecorded = append(unrecorded, rel)
+			return nil
+		case hashes[rel] != contentHash(current):
+			s.Wool.Warn("keeping modified file", wool.FileField(rel))
+			skipped = append(skipped, rel)
+			return nil
+		}
+		err = os.MkdirAll(filepath.Dir(target), 0o755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(target, content, 0o644)
+		if err != nil {
+			return err
+		}
+		hashes[rel] = contentHash(content)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, s.Wool.Wrapf(err, "cannot write templates")
+	}
+	return skipped, unrecorded, s.saveTemplateHashes(hashes)
+}
+
+// MigrateRestRoutes re-writes the route files, active and archived, with the current Extension schema
+func (s *Service) MigrateRestRoutes(ctx context.Context) error {
+	for _, dir := range []string{s.restRoutesLocation, s.archivedRestRoutesLocation} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		loader, err := resources.NewExtendedRestRouteLoader[Extension](ctx, dir)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot create route loader")
+		}
+		err = loader.Load(ctx)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot load routes")
+		}
+		err = loader.Save(ctx)
+		if err != nil {
+			return s.Wool.Wrapf(err, "cannot save routes")
+		}
+	}
+	return nil
+}
+
+// UpdateTemplates refreshes the configuration template, the route files and the READMEs:
+// it returns the changed files, the modified ones it kept and the ones left unchanged without record
+func (s *Builder) UpdateTemplates(ctx context.Context) ([]string, []string, []string, error) {
+	before, err := s.takeSnapshot()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = s.copyConfigTemplate()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = s.MigrateRestRoutes(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	skipped, unrecorded, err := s.WriteFactoryFiles(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = s.LoadRestRoutes(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	err = s.WriteRouteInventory(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	after, err := s.takeSnapshot()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	changed := s.diff(before, after)
+	for _, f := range changed {
+		s.Wool.Info("updated", wool.FileField(f))
+	}
+	return changed, skipped, unrecorded, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/codefly-dev/core/agents/services"
+)
+
+func TestWriteFactoryFiles(t *testing.T) {
+	ctx := context.Background()
+	guide := "GETTING_STARTED.md"
+	tcs := []struct {
+		name string
+		// hashes of the manifest, none without manifest
+		hashes     map[string]string
+		skipped    []string
+		unrecorded []string
+		// the guide is refreshed on the second update
+		refreshed bool
+	}{
+		{"modified since generated", map[string]string{guide: contentHash([]byte("generated"))}, nil, nil, true},
+		{"modified by the user", map[string]string{guide: contentHash([]byte("other"))}, []string{guide}, nil, false},
+		{"no manifest", nil, nil, []string{guide}, true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewBuilder()
+			s.Location = t.TempDir()
+			s.Information = &services.Information{}
+			err := os.WriteFile(s.Local(guide), []byte("generated"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.hashes != nil {
+				err = s.saveTemplateHashes(tc.hashes)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			skipped, unrecorded, err := s.WriteFactoryFiles(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(skipped, tc.skipped) {
+				t.Errorf("skipped = %v; want %v", skipped, tc.skipped)
+			}
+			if !reflect.DeepEqual(unrecorded, tc.unrecorded) {
+				t.Errorf("unrecorded = %v; want %v", unrecorded, tc.unrecorded)
+			}
+			if _, err := os.Stat(s.Local(filepath.Join("routing", "config", "settings", "README.md"))); err != nil {
+				t.Errorf("missing files should be written: %v", err)
+			}
+
+			_, _, err = s.WriteFactoryFiles(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(s.Local(guide))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if refreshed := string(content) != "generated"; refreshed != tc.refreshed {
+				t.Errorf("refreshed = %v; want %v", refreshed, tc.refreshed)
+			}
+		})
+	}
+}